@@ -0,0 +1,220 @@
+// Comando tmdb-collector administra o banco de um catálogo (SQLite,
+// PostgreSQL ou MySQL) e executa os perfis de coleta da configuração.
+//
+// Uso:
+//
+//	tmdb-collector migrate -db media.db
+//	tmdb-collector verify -db media.db [-fix]
+//	tmdb-collector verify -driver postgres -dsn "postgres://..." [-fix]
+//	tmdb-collector sync -config config.json [-profile nome,outro]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/api"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/collector"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	var err error
+	switch cmd {
+	case "migrate":
+		err = runMigrate(args)
+	case "verify":
+		err = runVerify(args)
+	case "sync":
+		err = runSync(args)
+	case "-h", "-help", "--help", "help":
+		usage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "comando desconhecido: %s\n", cmd)
+		usage()
+		os.Exit(2)
+	}
+	var usageErr usageError
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return
+	case errors.As(err, &usageErr):
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// usageError é um erro de fs.Parse, que o FlagSet já imprimiu junto com o
+// uso do comando.
+type usageError struct{ err error }
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `uso: tmdb-collector <comando> [opções]
+
+comandos:
+  migrate   aplica as migrações pendentes
+  verify    compara o banco com o esquema esperado
+  sync      executa os perfis de coleta da configuração
+
+Use "tmdb-collector <comando> -h" para ver as opções.`)
+}
+
+// dbFlags registra as opções comuns de conexão e devolve a função que monta,
+// depois de fs.Parse, a seção database equivalente.
+func dbFlags(fs *flag.FlagSet) func() config.DatabaseConfig {
+	driver := fs.String("driver", config.DefaultDBDriver, "driver do banco: sqlite3, postgres ou mysql")
+	dsn := fs.String("dsn", "", "DSN do banco (obrigatório com postgres e mysql; com sqlite3, substitui -db)")
+	path := fs.String("db", config.DefaultDBFilename, "arquivo do banco SQLite")
+	prefix := fs.String("prefix", "", "prefixo dos nomes das tabelas")
+	return func() config.DatabaseConfig {
+		return config.DatabaseConfig{Driver: *driver, DSN: *dsn, Filename: *path, TablePrefix: *prefix}
+	}
+}
+
+func openDatabase(cfg config.DatabaseConfig) (*database.Database, error) {
+	if cfg.DSN == "" && cfg.Driver != config.DefaultDBDriver {
+		return nil, fmt.Errorf("-dsn é obrigatório com o driver %s", cfg.Driver)
+	}
+	return database.NewDatabaseFromConfig(cfg)
+}
+
+// openExisting não cria o arquivo SQLite quando ele não existe, para que
+// verify não relate um banco vazio.
+func openExisting(cfg config.DatabaseConfig) (*database.Database, error) {
+	if cfg.Driver == config.DefaultDBDriver && cfg.DSN == "" {
+		if _, err := os.Stat(cfg.Filename); err != nil {
+			return nil, fmt.Errorf("erro ao abrir o banco: %v", err)
+		}
+	}
+	return openDatabase(cfg)
+}
+
+func runMigrate(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	dbConfig := dbFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+
+	db, err := openDatabase(dbConfig())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.Migrate(context.Background()); err != nil {
+		return err
+	}
+	version, err := db.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("banco na versão %d\n", version)
+	return nil
+}
+
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	dbConfig := dbFlags(fs)
+	fix := fs.Bool("fix", false, "imprime o SQL que corrige os problemas encontrados")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+
+	db, err := openExisting(dbConfig())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	report, err := db.Verify(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if *fix {
+		fmt.Print(report.FixSQL())
+	} else {
+		for _, issue := range report.Issues {
+			fmt.Println(issue)
+		}
+	}
+	if report.OK() {
+		fmt.Fprintf(os.Stderr, "esquema ok (%s, versão %d)\n", report.Dialect, report.Version)
+		return nil
+	}
+	return fmt.Errorf("%d problema(s) no esquema", len(report.Issues))
+}
+
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	path := fs.String("config", "config.json", "arquivo de configuração (.json, .yaml, .yml ou .toml)")
+	profiles := fs.String("profile", "", "perfis a executar, separados por vírgula (padrão: todos)")
+	list := fs.Bool("list", false, "lista os perfis da configuração e sai")
+	if err := fs.Parse(args); err != nil {
+		return usageError{err}
+	}
+
+	cfg, err := config.Load(*path)
+	if err != nil {
+		return err
+	}
+	if *list {
+		for _, name := range cfg.ProfileNames() {
+			p, _ := cfg.Profile(name)
+			fmt.Printf("%s\t%s\t%d página(s)\n", p.Name, p.MediaType, p.Pages)
+		}
+		return nil
+	}
+	names := profileNames(*profiles)
+	if len(names) == 0 && len(cfg.Profiles) == 0 {
+		return fmt.Errorf("nenhum perfil em %s", *path)
+	}
+
+	client, err := api.NewTMDBClientFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	db, err := database.NewDatabaseFromConfig(cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	c := collector.NewCollector(client, db)
+	results, err := c.SyncProfiles(context.Background(), names...)
+	for _, r := range results {
+		fmt.Printf("%s: %d página(s), %d inserido(s), %d atualizado(s), %d inalterado(s), %d removido(s), %d erro(s)\n",
+			r.Name, r.Pages, r.Saved.Inserted, r.Saved.Updated, r.Saved.Unchanged, r.Removed, len(r.Errors))
+	}
+	return err
+}
+
+// profileNames separa a lista de -profile, ignorando espaços em volta dos
+// nomes e itens vazios ("a, b," equivale a "a,b").
+func profileNames(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
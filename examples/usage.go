@@ -1,11 +1,8 @@
 package main
 
 import (
-	"database/sql"
-	"encoding/json"
 	"fmt"
 	"log"
-	"os"
 
 	"github.com/sshturbo/TMDB-Collector-Lib/pkg/api"
 	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
@@ -15,29 +12,22 @@ import (
 )
 
 func main() {
-	// Lê a configuração de um arquivo config.json
-	f, err := os.Open("config.json")
+	// Lê a configuração de config.json, com padrões e variáveis TMDB_*
+	cfg, err := config.Load("config.json")
 	if err != nil {
-		log.Fatal("Erro ao abrir config.json:", err)
-	}
-	defer f.Close()
-	var cfg config.Config
-	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
-		log.Fatal("Erro ao decodificar config.json:", err)
+		log.Fatal(err)
 	}
 
 	// Inicializa o cliente TMDB
-	tmdbClient := api.NewTMDBClient(&cfg)
+	tmdbClient := api.NewTMDBClient(cfg)
 
-	// Abre o banco de dados já existente
-	dbConn, err := sql.Open("sqlite3", "meubanco.db")
+	// Abre o banco da seção database da configuração (por padrão, o
+	// SQLite media.db) e cria ou atualiza as tabelas
+	db, err := database.NewDatabaseFromConfig(cfg.Database, database.WithAutoMigrate())
 	if err != nil {
-		log.Fatal("Erro ao conectar ao banco de dados:", err)
+		log.Fatal("Erro ao abrir o banco de dados:", err)
 	}
-	defer dbConn.Close()
-
-	// Usa o banco já aberto na biblioteca
-	db := database.NewDatabaseFromDB(dbConn)
+	defer db.Close()
 
 	// Buscar e salvar gêneros de filmes
 	movieGenres, err := tmdbClient.FetchMovieGenres()
@@ -54,11 +44,12 @@ func main() {
 		log.Fatalf("Erro ao buscar filmes: %v\n", err)
 	}
 	if len(movies) > 0 {
-		err := db.SaveMoviesBulk(movies)
+		result, err := db.SaveMoviesBulkWithResult(movies)
 		if err != nil {
 			log.Printf("Erro ao salvar filmes em lote: %v\n", err)
 		} else {
-			fmt.Printf("%d filmes salvos!\n", len(movies))
+			fmt.Printf("%d filmes novos, %d atualizados, %d inalterados\n",
+				result.Inserted, result.Updated, result.Unchanged)
 		}
 	}
 }
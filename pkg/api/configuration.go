@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+const defaultConfigCacheTTL = 24 * time.Hour
+
+// Configuration é a resposta do endpoint /configuration.
+type Configuration struct {
+	Images     models.ImageConfig `json:"images"`
+	ChangeKeys []string           `json:"change_keys"`
+}
+
+// As respostas de /configuration mudam raramente, então ficam em cache pelo
+// período de config.TMDB.ConfigCacheTTL.
+type responseCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	body    []byte
+	expires time.Time
+}
+
+func newResponseCache(ttl time.Duration) *responseCache {
+	return &responseCache{ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// configCacheTTL interpreta config.TMDB.ConfigCacheTTL; vazio usa 24h.
+func configCacheTTL(cfg *config.Config) (time.Duration, error) {
+	if cfg.TMDB.ConfigCacheTTL == "" {
+		return defaultConfigCacheTTL, nil
+	}
+	ttl, err := time.ParseDuration(cfg.TMDB.ConfigCacheTTL)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("tmdb.config_cache_ttl inválido: %q (ex.: \"24h\")", cfg.TMDB.ConfigCacheTTL)
+	}
+	return ttl, nil
+}
+
+func (c *TMDBClient) getCachedJSON(path string, params url.Values, out interface{}) error {
+	key := path + "?" + params.Encode()
+	now := c.cache.now()
+
+	c.cache.mu.Lock()
+	entry, ok := c.cache.entries[key]
+	c.cache.mu.Unlock()
+
+	if !ok || now.After(entry.expires) {
+		body, err := c.getBody(path, params)
+		if err != nil {
+			return err
+		}
+		entry = cacheEntry{body: body, expires: now.Add(c.cache.ttl)}
+		c.cache.mu.Lock()
+		c.cache.entries[key] = entry
+		c.cache.mu.Unlock()
+	}
+
+	if err := json.Unmarshal(entry.body, out); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta: %v", err)
+	}
+	return nil
+}
+
+// ClearConfigurationCache descarta as respostas de /configuration em cache.
+func (c *TMDBClient) ClearConfigurationCache() {
+	c.cache.mu.Lock()
+	defer c.cache.mu.Unlock()
+	c.cache.entries = make(map[string]cacheEntry)
+}
+
+func (c *TMDBClient) GetConfiguration() (*Configuration, error) {
+	var cfg Configuration
+	if err := c.getCachedJSON("/configuration", url.Values{}, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// LoadImageConfiguration busca /configuration e guarda no cliente o
+// secure_base_url e as listas de tamanhos do TMDB, devolvidos depois por
+// ImageConfig.
+func (c *TMDBClient) LoadImageConfiguration() (models.ImageConfig, error) {
+	cfg, err := c.GetConfiguration()
+	if err != nil {
+		return models.ImageConfig{}, err
+	}
+	c.imagesMu.Lock()
+	c.images = &cfg.Images
+	c.imagesMu.Unlock()
+	return cfg.Images, nil
+}
+
+// ImageConfig devolve a configuração de imagens carregada por
+// LoadImageConfiguration, ou models.DefaultImageConfig se ela ainda não foi
+// carregada. Use o resultado com Movie.PosterURL, TVShow.BackdropURL e
+// images.Mirror.
+func (c *TMDBClient) ImageConfig() models.ImageConfig {
+	c.imagesMu.RLock()
+	defer c.imagesMu.RUnlock()
+	if c.images == nil {
+		return models.DefaultImageConfig
+	}
+	return *c.images
+}
+
+func (c *TMDBClient) GetCountries() ([]models.Country, error) {
+	params := url.Values{}
+	params.Set("language", c.config.TMDB.Language)
+	var countries []models.Country
+	if err := c.getCachedJSON("/configuration/countries", params, &countries); err != nil {
+		return nil, err
+	}
+	return countries, nil
+}
+
+func (c *TMDBClient) GetLanguages() ([]models.Language, error) {
+	var languages []models.Language
+	if err := c.getCachedJSON("/configuration/languages", url.Values{}, &languages); err != nil {
+		return nil, err
+	}
+	return languages, nil
+}
+
+func (c *TMDBClient) GetTimezones() ([]models.Timezone, error) {
+	var timezones []models.Timezone
+	if err := c.getCachedJSON("/configuration/timezones", url.Values{}, &timezones); err != nil {
+		return nil, err
+	}
+	return timezones, nil
+}
+
+func (c *TMDBClient) GetJobs() ([]models.Department, error) {
+	var jobs []models.Department
+	if err := c.getCachedJSON("/configuration/jobs", url.Values{}, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// GetPrimaryTranslations devolve os idiomas (ex.: "pt-BR") com tradução
+// primária no TMDB.
+func (c *TMDBClient) GetPrimaryTranslations() ([]string, error) {
+	var translations []string
+	if err := c.getCachedJSON("/configuration/primary_translations", url.Values{}, &translations); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+// ValidateLanguage confere se config.TMDB.Language é uma das traduções
+// primárias do TMDB.
+func (c *TMDBClient) ValidateLanguage() error {
+	translations, err := c.GetPrimaryTranslations()
+	if err != nil {
+		return err
+	}
+	for _, t := range translations {
+		if t == c.config.TMDB.Language {
+			return nil
+		}
+	}
+	return fmt.Errorf("idioma %q não está entre as traduções primárias do TMDB", c.config.TMDB.Language)
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// newTestClient devolve um cliente que consulta handler em vez do TMDB.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *TMDBClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	cfg := config.Default()
+	cfg.TMDB.APIKey = "chave"
+	cfg.TMDB.BaseURL = srv.URL
+	return NewTMDBClient(cfg)
+}
+
+func TestLoadImageConfiguration(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"images": {"secure_base_url": "https://img.example.com/p/", "poster_sizes": ["w200", "original"]}}`))
+	})
+	if got := c.ImageConfig(); !reflect.DeepEqual(got, models.DefaultImageConfig) {
+		t.Errorf("ImageConfig antes de carregar = %+v, esperado DefaultImageConfig", got)
+	}
+	if _, err := c.LoadImageConfiguration(); err != nil {
+		t.Fatalf("LoadImageConfiguration: %v", err)
+	}
+	images := c.ImageConfig()
+	if got, want := images.PosterURL("/a.jpg", "w200"), "https://img.example.com/p/w200/a.jpg"; got != want {
+		t.Errorf("PosterURL = %q, esperado %q", got, want)
+	}
+	// Outro cliente não enxerga a configuração carregada.
+	other := NewTMDBClient(c.Config())
+	if got := other.ImageConfig(); !reflect.DeepEqual(got, models.DefaultImageConfig) {
+		t.Errorf("ImageConfig de outro cliente = %+v", got)
+	}
+}
+
+func TestNewTMDBClientFromConfigTTL(t *testing.T) {
+	cfg := config.Default()
+	cfg.TMDB.ConfigCacheTTL = "1 dia"
+	if _, err := NewTMDBClientFromConfig(cfg); err == nil {
+		t.Error("NewTMDBClientFromConfig aceitou um TTL inválido")
+	}
+	// NewTMDBClient mantém o padrão de 24h.
+	if c := NewTMDBClient(cfg); c.cache.ttl != defaultConfigCacheTTL {
+		t.Errorf("TTL = %v, esperado %v", c.cache.ttl, defaultConfigCacheTTL)
+	}
+	cfg.TMDB.ConfigCacheTTL = "90m"
+	c, err := NewTMDBClientFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewTMDBClientFromConfig: %v", err)
+	}
+	if c.cache.ttl != 90*time.Minute {
+		t.Errorf("TTL = %v, esperado 90m", c.cache.ttl)
+	}
+}
+
+func TestConfigurationCache(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`["pt-BR", "en-US"]`))
+	})
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.cache.now = func() time.Time { return now }
+
+	fetch := func() {
+		t.Helper()
+		if _, err := c.GetPrimaryTranslations(); err != nil {
+			t.Fatalf("GetPrimaryTranslations: %v", err)
+		}
+	}
+	fetch()
+	now = now.Add(defaultConfigCacheTTL)
+	fetch()
+	if requests != 1 {
+		t.Errorf("requisições dentro do TTL = %d, esperado 1", requests)
+	}
+	now = now.Add(time.Second)
+	fetch()
+	if requests != 2 {
+		t.Errorf("requisições depois do TTL = %d, esperado 2", requests)
+	}
+	c.ClearConfigurationCache()
+	fetch()
+	if requests != 3 {
+		t.Errorf("requisições depois de ClearConfigurationCache = %d, esperado 3", requests)
+	}
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// Enrichment define quantas requisições extras são feitas por item de uma
+// listagem (discover/search).
+type Enrichment int
+
+const (
+	// EnrichNone devolve apenas os dados da listagem, sem requisições extras.
+	EnrichNone Enrichment = iota
+	// EnrichTrailers busca os vídeos de cada item e preenche TrailerURL.
+	EnrichTrailers
+	// EnrichDetails busca os detalhes completos de cada item com
+	// append_to_response=videos, preenchendo gêneros, vídeos e trailer
+	// em uma única requisição.
+	EnrichDetails
+)
+
+const defaultEnrichConcurrency = 10
+
+type ListOptions struct {
+	Enrichment Enrichment
+	// Concurrency limita as requisições de enriquecimento simultâneas.
+	// Zero usa 10.
+	Concurrency int
+}
+
+// EnrichmentError registra a falha ao enriquecer um item específico. O item
+// continua presente no resultado, apenas sem os dados extras.
+type EnrichmentError struct {
+	ID  int
+	Err error
+}
+
+func (e EnrichmentError) Error() string {
+	return fmt.Sprintf("erro ao enriquecer item %d: %v", e.ID, e.Err)
+}
+
+func (e EnrichmentError) Unwrap() error {
+	return e.Err
+}
+
+type MovieResults struct {
+	Page         int
+	TotalPages   int
+	TotalResults int
+	Movies       []models.Movie
+	Errors       []EnrichmentError
+}
+
+type TVShowResults struct {
+	Page         int
+	TotalPages   int
+	TotalResults int
+	Shows        []models.TVShow
+	Errors       []EnrichmentError
+}
+
+type movieDetails struct {
+	models.Movie
+	Genres []models.Genre `json:"genres"`
+	Videos VideoResponse  `json:"videos"`
+}
+
+type tvShowDetails struct {
+	models.TVShow
+	Genres []models.Genre `json:"genres"`
+	Videos VideoResponse  `json:"videos"`
+}
+
+// GetMovieDetails busca os detalhes de um filme junto com seus vídeos.
+func (c *TMDBClient) GetMovieDetails(movieID int) (*models.Movie, error) {
+	var details movieDetails
+	if err := c.getJSON(fmt.Sprintf("/movie/%d", movieID), c.detailsParams(), &details); err != nil {
+		return nil, err
+	}
+	movie := details.Movie
+	movie.GenreIDs = genreIDs(details.Genres)
+	movie.Videos = c.prepareVideos(models.MediaTypeMovie, movieID, details.Videos.Results)
+	if video, ok := c.videoPolicy.Select(movie.Videos); ok {
+		movie.TrailerURL = video.URL()
+	}
+	return &movie, nil
+}
+
+// GetTVShowDetails busca os detalhes de uma série junto com seus vídeos.
+func (c *TMDBClient) GetTVShowDetails(showID int) (*models.TVShow, error) {
+	var details tvShowDetails
+	if err := c.getJSON(fmt.Sprintf("/tv/%d", showID), c.detailsParams(), &details); err != nil {
+		return nil, err
+	}
+	show := details.TVShow
+	show.GenreIDs = genreIDs(details.Genres)
+	show.Videos = c.prepareVideos(models.MediaTypeTV, showID, details.Videos.Results)
+	if video, ok := c.videoPolicy.Select(show.Videos); ok {
+		show.TrailerURL = video.URL()
+	}
+	return &show, nil
+}
+
+func (c *TMDBClient) detailsParams() url.Values {
+	params := url.Values{}
+	params.Set("language", c.config.TMDB.Language)
+	params.Set("append_to_response", "videos")
+	if codes := c.videoPolicy.videoLanguages(); len(codes) > 0 {
+		params.Set("include_video_language", strings.Join(codes, ","))
+	}
+	return params
+}
+
+func (c *TMDBClient) prepareVideos(mediaType string, id int, videos []models.Video) []models.Video {
+	for i := range videos {
+		videos[i].MediaType = mediaType
+		videos[i].MediaID = id
+	}
+	c.videoPolicy.Sort(videos)
+	return videos
+}
+
+func (c *TMDBClient) enrichMovie(m *models.Movie, enrichment Enrichment) error {
+	switch enrichment {
+	case EnrichTrailers:
+		trailer, err := c.GetMovieTrailer(m.ID)
+		if err != nil {
+			return err
+		}
+		m.TrailerURL = trailer
+	case EnrichDetails:
+		details, err := c.GetMovieDetails(m.ID)
+		if err != nil {
+			return err
+		}
+		*m = *details
+	}
+	return nil
+}
+
+func (c *TMDBClient) enrichTVShow(s *models.TVShow, enrichment Enrichment) error {
+	switch enrichment {
+	case EnrichTrailers:
+		trailer, err := c.GetTVShowTrailer(s.ID)
+		if err != nil {
+			return err
+		}
+		s.TrailerURL = trailer
+	case EnrichDetails:
+		details, err := c.GetTVShowDetails(s.ID)
+		if err != nil {
+			return err
+		}
+		*s = *details
+	}
+	return nil
+}
+
+// enrich executa fn para cada item com concorrência limitada e devolve os
+// erros na ordem dos itens.
+func (c *TMDBClient) enrich(n int, opts ListOptions, fn func(i int) error, id func(i int) int) []EnrichmentError {
+	if opts.Enrichment == EnrichNone || n == 0 {
+		return nil
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultEnrichConcurrency
+	}
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			errs[i] = fn(i)
+			<-sem
+		}(i)
+	}
+	wg.Wait()
+
+	var result []EnrichmentError
+	for i, err := range errs {
+		if err != nil {
+			result = append(result, EnrichmentError{ID: id(i), Err: err})
+		}
+	}
+	return result
+}
+
+func genreIDs(genres []models.Genre) []int {
+	ids := make([]int, 0, len(genres))
+	for _, g := range genres {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
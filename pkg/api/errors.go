@@ -0,0 +1,26 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// APIError é a resposta de erro do TMDB, com o status HTTP e o corpo
+// devolvido.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("erro na API (status %d): %s", e.StatusCode, e.Body)
+}
+
+// IsNotFound indica se err (ou um erro encadeado nele, como em
+// EnrichmentError) é um 404 do TMDB, o que acontece quando o ID foi
+// removido ou mesclado a outro.
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+)
+
+// ParseEnrichment converte o enriquecimento de um perfil ("none",
+// "trailers" ou "details") em Enrichment. Vazio equivale a "none".
+func ParseEnrichment(s string) (Enrichment, error) {
+	switch s {
+	case "", "none":
+		return EnrichNone, nil
+	case "trailers":
+		return EnrichTrailers, nil
+	case "details":
+		return EnrichDetails, nil
+	}
+	return EnrichNone, fmt.Errorf("enriquecimento inválido: %q", s)
+}
+
+// ProfileListOptions devolve as ListOptions do enriquecimento de um perfil.
+func ProfileListOptions(p config.Profile) (ListOptions, error) {
+	enrichment, err := ParseEnrichment(p.Enrichment)
+	if err != nil {
+		return ListOptions{}, err
+	}
+	return ListOptions{Enrichment: enrichment, Concurrency: p.Concurrency}, nil
+}
+
+// DiscoverMoviesWithProfile busca uma página de /discover/movie com os
+// filtros, a ordenação e o idioma de um perfil; ver config.Config.Profile.
+func (c *TMDBClient) DiscoverMoviesWithProfile(page int, p config.Profile, opts ListOptions) (*MovieResults, error) {
+	params, err := discoverParams(page, p, "release_date")
+	if err != nil {
+		return nil, err
+	}
+	if p.IncludeVideo != nil {
+		params.Set("include_video", strconv.FormatBool(*p.IncludeVideo))
+	}
+	if p.Region != "" {
+		params.Set("region", p.Region)
+	}
+	return c.listMovies("/discover/movie", params, opts)
+}
+
+// DiscoverTVShowsWithProfile segue as regras de DiscoverMoviesWithProfile
+// para /discover/tv.
+func (c *TMDBClient) DiscoverTVShowsWithProfile(page int, p config.Profile, opts ListOptions) (*TVShowResults, error) {
+	params, err := discoverParams(page, p, "first_air_date")
+	if err != nil {
+		return nil, err
+	}
+	return c.listTVShows("/discover/tv", params, opts)
+}
+
+// discoverParams monta os parâmetros comuns de /discover. As datas são
+// resolvidas no momento da requisição; campos vazios não são enviados.
+func discoverParams(page int, p config.Profile, dateField string) (url.Values, error) {
+	params := url.Values{}
+	params.Set("page", strconv.Itoa(page))
+	if p.Sort.Field != "" {
+		params.Set("sort_by", p.Sort.Field+"."+p.Sort.Direction)
+	}
+	if p.IncludeAdult != nil {
+		params.Set("include_adult", strconv.FormatBool(*p.IncludeAdult))
+	}
+	if p.Language != "" {
+		params.Set("language", p.Language)
+	}
+	if len(p.OriginalLanguages) > 0 {
+		params.Set("with_original_language", strings.Join(p.OriginalLanguages, "|"))
+	}
+	if len(p.OriginCountries) > 0 {
+		params.Set("with_origin_country", strings.Join(p.OriginCountries, "|"))
+	}
+	if len(p.Genres) > 0 {
+		ids := make([]string, len(p.Genres))
+		for i, id := range p.Genres {
+			ids[i] = strconv.Itoa(id)
+		}
+		params.Set("with_genres", strings.Join(ids, ","))
+	}
+	if p.MinVoteAverage > 0 {
+		params.Set("vote_average.gte", strconv.FormatFloat(p.MinVoteAverage, 'f', -1, 64))
+	}
+	if p.MinVoteCount > 0 {
+		params.Set("vote_count.gte", strconv.Itoa(p.MinVoteCount))
+	}
+
+	now := time.Now()
+	for _, bound := range []struct{ suffix, expr string }{
+		{".gte", p.MinReleaseDate},
+		{".lte", p.MaxReleaseDate},
+	} {
+		date, err := config.ResolveDate(bound.expr, now)
+		if err != nil {
+			return nil, err
+		}
+		if date != "" {
+			params.Set(dateField+bound.suffix, date)
+		}
+	}
+	return params, nil
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+)
+
+func TestDiscoverWithProfile(t *testing.T) {
+	var path string
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		query.Del("api_key")
+		w.Write([]byte(`{"page": 1, "total_pages": 1, "results": []}`))
+	})
+	yes, no := true, false
+	cfg := c.Config()
+	cfg.Profiles = []config.Profile{
+		{
+			Name:              "nacionais",
+			MediaType:         "movie",
+			Language:          "pt-BR",
+			OriginalLanguages: []string{"pt", "es"},
+			OriginCountries:   []string{"BR", "AR"},
+			Region:            "BR",
+			Genres:            []int{18, 35},
+			MinVoteAverage:    6.5,
+			MinVoteCount:      50,
+			MinReleaseDate:    "2024-01-01",
+			MaxReleaseDate:    "2024-06-30",
+			IncludeAdult:      &no,
+			IncludeVideo:      &yes,
+			Sort:              config.SortConfig{Field: "release_date", Direction: "asc"},
+		},
+		// Sem filtros, o perfil herda idioma, ordenação e include_adult.
+		{Name: "series", MediaType: "tv", MinReleaseDate: "2020-01-01"},
+	}
+
+	movies, err := cfg.Profile("nacionais")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if _, err := c.DiscoverMoviesWithProfile(2, movies, ListOptions{}); err != nil {
+		t.Fatalf("DiscoverMoviesWithProfile: %v", err)
+	}
+	want := url.Values{
+		"page":                   {"2"},
+		"sort_by":                {"release_date.asc"},
+		"include_adult":          {"false"},
+		"include_video":          {"true"},
+		"language":               {"pt-BR"},
+		"region":                 {"BR"},
+		"with_original_language": {"pt|es"},
+		"with_origin_country":    {"BR|AR"},
+		"with_genres":            {"18,35"},
+		"vote_average.gte":       {"6.5"},
+		"vote_count.gte":         {"50"},
+		"release_date.gte":       {"2024-01-01"},
+		"release_date.lte":       {"2024-06-30"},
+	}
+	if path != "/discover/movie" || !reflect.DeepEqual(query, want) {
+		t.Errorf("requisição = %s %v\nesperado /discover/movie %v", path, query, want)
+	}
+
+	shows, err := cfg.Profile("series")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if _, err := c.DiscoverTVShowsWithProfile(1, shows, ListOptions{}); err != nil {
+		t.Fatalf("DiscoverTVShowsWithProfile: %v", err)
+	}
+	want = url.Values{
+		"page":               {"1"},
+		"sort_by":            {"popularity.desc"},
+		"include_adult":      {"false"},
+		"language":           {"en-US"},
+		"first_air_date.gte": {"2020-01-01"},
+	}
+	if path != "/discover/tv" || !reflect.DeepEqual(query, want) {
+		t.Errorf("requisição = %s %v\nesperado /discover/tv %v", path, query, want)
+	}
+
+	shows.MaxReleaseDate = "ontem"
+	if _, err := c.DiscoverTVShowsWithProfile(1, shows, ListOptions{}); err == nil {
+		t.Error("DiscoverTVShowsWithProfile aceitou uma data inválida")
+	}
+}
+
+func TestProfileListOptions(t *testing.T) {
+	tests := []struct {
+		enrichment string
+		want       Enrichment
+	}{
+		{"", EnrichNone},
+		{"none", EnrichNone},
+		{"trailers", EnrichTrailers},
+		{"details", EnrichDetails},
+	}
+	for _, tt := range tests {
+		opts, err := ProfileListOptions(config.Profile{Enrichment: tt.enrichment, Concurrency: 3})
+		if err != nil || opts != (ListOptions{Enrichment: tt.want, Concurrency: 3}) {
+			t.Errorf("ProfileListOptions(%q) = %+v, %v", tt.enrichment, opts, err)
+		}
+	}
+	if _, err := ProfileListOptions(config.Profile{Enrichment: "tudo"}); err == nil {
+		t.Error("ProfileListOptions aceitou um enriquecimento inválido")
+	}
+}
@@ -4,17 +4,24 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
+	"time"
+
 	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
 	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
 )
 
 type TMDBClient struct {
-	config *config.Config
-	client *http.Client
+	config      *config.Config
+	client      *http.Client
+	videoPolicy VideoPolicy
+	cache       *responseCache
+
+	imagesMu sync.RWMutex
+	images   *models.ImageConfig
 }
 
 type TMDBResponse struct {
@@ -25,62 +32,68 @@ type TMDBResponse struct {
 }
 
 type VideoResponse struct {
-	Results []struct {
-		Key      string `json:"key"`
-		Site     string `json:"site"`
-		Type     string `json:"type"`
-		Official bool   `json:"official"`
-	} `json:"results"`
+	Results []models.Video `json:"results"`
 }
 
+// NewTMDBClient cria um cliente com a configuração cfg. Um
+// tmdb.config_cache_ttl inválido é trocado pelo padrão de 24h; use
+// NewTMDBClientFromConfig para recebê-lo como erro.
 func NewTMDBClient(cfg *config.Config) *TMDBClient {
-	return &TMDBClient{
-		config: cfg,
-		client: &http.Client{},
+	ttl, err := configCacheTTL(cfg)
+	if err != nil {
+		ttl = defaultConfigCacheTTL
 	}
+	return newTMDBClient(cfg, ttl)
 }
 
-func (c *TMDBClient) SearchMovies(query string, page int) ([]models.Movie, error) {
-	url := fmt.Sprintf("%s/search/movie?api_key=%s&query=%s&page=%d", c.config.TMDB.BaseURL, c.config.TMDB.APIKey, url.QueryEscape(query), page)
-
-	resp, err := c.client.Get(url)
+// NewTMDBClientFromConfig é NewTMDBClient, mas devolve erro se
+// tmdb.config_cache_ttl for inválido.
+func NewTMDBClientFromConfig(cfg *config.Config) (*TMDBClient, error) {
+	ttl, err := configCacheTTL(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("erro na requisição: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return newTMDBClient(cfg, ttl), nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta: %v", err)
+func newTMDBClient(cfg *config.Config, ttl time.Duration) *TMDBClient {
+	return &TMDBClient{
+		config:      cfg,
+		client:      &http.Client{},
+		videoPolicy: DefaultVideoPolicy(cfg.TMDB.Language),
+		cache:       newResponseCache(ttl),
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
-	}
+// Config devolve a configuração usada pelo cliente.
+func (c *TMDBClient) Config() *config.Config {
+	return c.config
+}
 
-	var response TMDBResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %v", err)
-	}
+// SetVideoPolicy troca a política usada para escolher trailers. Deve ser
+// chamada antes de iniciar buscas concorrentes.
+func (c *TMDBClient) SetVideoPolicy(policy VideoPolicy) {
+	c.videoPolicy = policy
+}
 
-	var movies []models.Movie
-	if err := json.Unmarshal(response.Results, &movies); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar filmes: %v", err)
+func (c *TMDBClient) getJSON(path string, params url.Values, out interface{}) error {
+	body, err := c.getBody(path, params)
+	if err != nil {
+		return err
 	}
-
-	for i := range movies {
-		if movies[i].PosterPath != "" {
-			movies[i].PosterPath = c.config.TMDB.ImageBaseURL + movies[i].PosterPath
-		}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("erro ao decodificar resposta: %v", err)
 	}
-
-	return movies, nil
+	return nil
 }
 
-func (c *TMDBClient) SearchTVShows(query string, page int) ([]models.TVShow, error) {
-	url := fmt.Sprintf("%s/search/tv?api_key=%s&query=%s&page=%d", c.config.TMDB.BaseURL, c.config.TMDB.APIKey, url.QueryEscape(query), page)
+func (c *TMDBClient) getBody(path string, params url.Values) ([]byte, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("api_key", c.config.TMDB.APIKey)
 
-	resp, err := c.client.Get(url)
+	resp, err := c.client.Get(c.config.TMDB.BaseURL + path + "?" + params.Encode())
 	if err != nil {
 		return nil, fmt.Errorf("erro na requisição: %v", err)
 	}
@@ -92,58 +105,75 @@ func (c *TMDBClient) SearchTVShows(query string, page int) ([]models.TVShow, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
+	return body, nil
+}
 
-	var response TMDBResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %v", err)
+func (c *TMDBClient) SearchMovies(query string, page int) ([]models.Movie, error) {
+	result, err := c.SearchMoviesWithOptions(query, page, ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return result.Movies, nil
+}
 
-	var shows []models.TVShow
-	if err := json.Unmarshal(response.Results, &shows); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar séries: %v", err)
-	}
+func (c *TMDBClient) SearchMoviesWithOptions(query string, page int, opts ListOptions) (*MovieResults, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("page", strconv.Itoa(page))
+	return c.listMovies("/search/movie", params, opts)
+}
 
-	for i := range shows {
-		if shows[i].PosterPath != "" {
-			shows[i].PosterPath = c.config.TMDB.ImageBaseURL + shows[i].PosterPath
-		}
+func (c *TMDBClient) SearchTVShows(query string, page int) ([]models.TVShow, error) {
+	result, err := c.SearchTVShowsWithOptions(query, page, ListOptions{})
+	if err != nil {
+		return nil, err
 	}
+	return result.Shows, nil
+}
 
-	return shows, nil
+func (c *TMDBClient) SearchTVShowsWithOptions(query string, page int, opts ListOptions) (*TVShowResults, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("page", strconv.Itoa(page))
+	return c.listTVShows("/search/tv", params, opts)
 }
 
+// DiscoverMovies mantém o comportamento original: busca o trailer de cada
+// filme e ignora falhas individuais. Use DiscoverMoviesWithOptions para
+// escolher o enriquecimento e receber os erros.
 func (c *TMDBClient) DiscoverMovies(page int) ([]models.Movie, error) {
-	url := fmt.Sprintf("%s/discover/movie?api_key=%s&page=%d&sort_by=%s.%s&include_adult=%v&include_video=%v&language=%s&release_date.lte=%s",
-		c.config.TMDB.BaseURL,
-		c.config.TMDB.APIKey,
-		page,
-		c.config.Fetch.Sort.Movies.Field,
-		c.config.Fetch.Sort.Movies.Direction,
-		c.config.Fetch.IncludeAdult,
-		c.config.Fetch.IncludeVideo,
-		c.config.TMDB.Language,
-		c.config.Fetch.MaxReleaseDate)
-
-	resp, err := c.client.Get(url)
+	result, err := c.DiscoverMoviesWithOptions(page, ListOptions{Enrichment: EnrichTrailers})
 	if err != nil {
-		return nil, fmt.Errorf("erro na requisição: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return result.Movies, nil
+}
 
-	body, err := ioutil.ReadAll(resp.Body)
+func (c *TMDBClient) DiscoverMoviesWithOptions(page int, opts ListOptions) (*MovieResults, error) {
+	return c.DiscoverMoviesWithProfile(page, c.config.FetchProfile(models.MediaTypeMovie), opts)
+}
+
+// DiscoverTVShows mantém o comportamento original: busca o trailer de cada
+// série e ignora falhas individuais. Use DiscoverTVShowsWithOptions para
+// escolher o enriquecimento e receber os erros.
+func (c *TMDBClient) DiscoverTVShows(page int) ([]models.TVShow, error) {
+	result, err := c.DiscoverTVShowsWithOptions(page, ListOptions{Enrichment: EnrichTrailers})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta: %v", err)
+		return nil, err
 	}
+	return result.Shows, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
-	}
+func (c *TMDBClient) DiscoverTVShowsWithOptions(page int, opts ListOptions) (*TVShowResults, error) {
+	return c.DiscoverTVShowsWithProfile(page, c.config.FetchProfile(models.MediaTypeTV), opts)
+}
 
+func (c *TMDBClient) listMovies(path string, params url.Values, opts ListOptions) (*MovieResults, error) {
 	var response TMDBResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %v", err)
+	if err := c.getJSON(path, params, &response); err != nil {
+		return nil, err
 	}
 
 	var movies []models.Movie
@@ -151,60 +181,22 @@ func (c *TMDBClient) DiscoverMovies(page int) ([]models.Movie, error) {
 		return nil, fmt.Errorf("erro ao decodificar filmes: %v", err)
 	}
 
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 10)
-
-	for i := range movies {
-		if movies[i].PosterPath != "" {
-			movies[i].PosterPath = c.config.TMDB.ImageBaseURL + movies[i].PosterPath
-		}
-		if movies[i].BackdropPath != "" {
-			movies[i].BackdropPath = c.config.TMDB.ImageBaseURL + movies[i].BackdropPath
-		}
-		wg.Add(1)
-		go func(m *models.Movie) {
-			defer wg.Done()
-			sem <- struct{}{}
-			if trailer, err := c.GetMovieTrailer(m.ID); err == nil {
-				m.TrailerURL = trailer
-			}
-			<-sem
-		}(&movies[i])
-	}
-	wg.Wait()
-
-	return movies, nil
-}
-
-func (c *TMDBClient) DiscoverTVShows(page int) ([]models.TVShow, error) {
-	url := fmt.Sprintf("%s/discover/tv?api_key=%s&page=%d&sort_by=%s.%s&include_adult=%v&language=%s&first_air_date.lte=%s",
-		c.config.TMDB.BaseURL,
-		c.config.TMDB.APIKey,
-		page,
-		c.config.Fetch.Sort.TVShows.Field,
-		c.config.Fetch.Sort.TVShows.Direction,
-		c.config.Fetch.IncludeAdult,
-		c.config.TMDB.Language,
-		c.config.Fetch.MaxReleaseDate)
-
-	resp, err := c.client.Get(url)
-	if err != nil {
-		return nil, fmt.Errorf("erro na requisição: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao ler resposta: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
+	result := &MovieResults{
+		Page:         response.Page,
+		TotalPages:   response.TotalPages,
+		TotalResults: response.TotalResults,
+		Movies:       movies,
 	}
+	result.Errors = c.enrich(len(movies), opts, func(i int) error {
+		return c.enrichMovie(&movies[i], opts.Enrichment)
+	}, func(i int) int { return movies[i].ID })
+	return result, nil
+}
 
+func (c *TMDBClient) listTVShows(path string, params url.Values, opts ListOptions) (*TVShowResults, error) {
 	var response TMDBResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("erro ao decodificar resposta: %v", err)
+	if err := c.getJSON(path, params, &response); err != nil {
+		return nil, err
 	}
 
 	var shows []models.TVShow
@@ -212,162 +204,49 @@ func (c *TMDBClient) DiscoverTVShows(page int) ([]models.TVShow, error) {
 		return nil, fmt.Errorf("erro ao decodificar séries: %v", err)
 	}
 
-	var wg sync.WaitGroup
-	sem := make(chan struct{}, 10)
-
-	for i := range shows {
-		if shows[i].PosterPath != "" {
-			shows[i].PosterPath = c.config.TMDB.ImageBaseURL + shows[i].PosterPath
-		}
-		if shows[i].BackdropPath != "" {
-			shows[i].BackdropPath = c.config.TMDB.ImageBaseURL + shows[i].BackdropPath
-		}
-		wg.Add(1)
-		go func(s *models.TVShow) {
-			defer wg.Done()
-			sem <- struct{}{}
-			if trailer, err := c.GetTVShowTrailer(s.ID); err == nil {
-				s.TrailerURL = trailer
-			}
-			<-sem
-		}(&shows[i])
-	}
-	wg.Wait()
-
-	return shows, nil
+	result := &TVShowResults{
+		Page:         response.Page,
+		TotalPages:   response.TotalPages,
+		TotalResults: response.TotalResults,
+		Shows:        shows,
+	}
+	result.Errors = c.enrich(len(shows), opts, func(i int) error {
+		return c.enrichTVShow(&shows[i], opts.Enrichment)
+	}, func(i int) int { return shows[i].ID })
+	return result, nil
+}
+
+func (c *TMDBClient) GetMovieVideos(movieID int) ([]models.Video, error) {
+	return c.getVideos(models.MediaTypeMovie, movieID)
+}
+
+func (c *TMDBClient) GetTVShowVideos(showID int) ([]models.Video, error) {
+	return c.getVideos(models.MediaTypeTV, showID)
 }
 
+// GetMovieTrailer devolve a URL do trailer escolhido pela política de
+// vídeos, ou "" quando o filme não tem trailer.
 func (c *TMDBClient) GetMovieTrailer(movieID int) (string, error) {
-	getTrailer := func(lang string) (string, error) {
-		url := fmt.Sprintf("%s/movie/%d/videos?api_key=%s&language=%s",
-			c.config.TMDB.BaseURL,
-			movieID,
-			c.config.TMDB.APIKey,
-			lang)
-
-		resp, err := c.client.Get(url)
-		if err != nil {
-			return "", err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
-		}
-
-		var videos VideoResponse
-		if err := json.Unmarshal(body, &videos); err != nil {
-			return "", err
-		}
-
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Trailer" && video.Official {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Trailer" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Teaser" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Clip" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-		return "", nil
-	}
-
-	trailer, err := getTrailer(c.config.TMDB.Language)
-	if trailer != "" {
-		return trailer, nil
-	}
-	if c.config.TMDB.Language != "en-US" {
-		trailer, err = getTrailer("en-US")
-		if trailer != "" {
-			return trailer, nil
-		}
-	}
-	log.Printf("Nenhum trailer encontrado para o filme %d", movieID)
-	return "", err
+	videos, err := c.GetMovieVideos(movieID)
+	if err != nil {
+		return "", err
+	}
+	if video, ok := c.videoPolicy.Select(videos); ok {
+		return video.URL(), nil
+	}
+	return "", nil
 }
 
+// GetTVShowTrailer segue a regra de GetMovieTrailer para séries.
 func (c *TMDBClient) GetTVShowTrailer(showID int) (string, error) {
-	getTrailer := func(lang string) (string, error) {
-		url := fmt.Sprintf("%s/tv/%d/videos?api_key=%s&language=%s",
-			c.config.TMDB.BaseURL,
-			showID,
-			c.config.TMDB.APIKey,
-			lang)
-
-		resp, err := c.client.Get(url)
-		if err != nil {
-			return "", err
-		}
-		defer resp.Body.Close()
-
-		body, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return "", err
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return "", fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
-		}
-
-		var videos VideoResponse
-		if err := json.Unmarshal(body, &videos); err != nil {
-			return "", err
-		}
-
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Trailer" && video.Official {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Trailer" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Teaser" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-		for _, video := range videos.Results {
-			if video.Site == "YouTube" && video.Type == "Clip" {
-				return fmt.Sprintf("https://www.youtube.com/watch?v=%s", video.Key), nil
-			}
-		}
-		return "", nil
-	}
-
-	trailer, err := getTrailer(c.config.TMDB.Language)
-	if trailer != "" {
-		return trailer, nil
-	}
-	if c.config.TMDB.Language != "en-US" {
-		trailer, err = getTrailer("en-US")
-		if trailer != "" {
-			return trailer, nil
-		}
-	}
-	log.Printf("Nenhum trailer encontrado para a série %d", showID)
-	return "", err
+	videos, err := c.GetTVShowVideos(showID)
+	if err != nil {
+		return "", err
+	}
+	if video, ok := c.videoPolicy.Select(videos); ok {
+		return video.URL(), nil
+	}
+	return "", nil
 }
 
 func (c *TMDBClient) FetchMovieGenres() ([]models.Genre, error) {
@@ -385,7 +264,7 @@ func (c *TMDBClient) FetchMovieGenres() ([]models.Genre, error) {
 		return nil, fmt.Errorf("erro ao ler resposta: %v", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	var result struct {
 		Genres []models.Genre `json:"genres"`
@@ -393,6 +272,10 @@ func (c *TMDBClient) FetchMovieGenres() ([]models.Genre, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("erro ao decodificar gêneros: %v", err)
 	}
+	for i := range result.Genres {
+		result.Genres[i].MediaType = models.MediaTypeMovie
+		result.Genres[i].Language = c.config.TMDB.Language
+	}
 	return result.Genres, nil
 }
 
@@ -411,7 +294,7 @@ func (c *TMDBClient) FetchTVShowGenres() ([]models.Genre, error) {
 		return nil, fmt.Errorf("erro ao ler resposta: %v", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("erro na API (status %d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	var result struct {
 		Genres []models.Genre `json:"genres"`
@@ -419,5 +302,9 @@ func (c *TMDBClient) FetchTVShowGenres() ([]models.Genre, error) {
 	if err := json.Unmarshal(body, &result); err != nil {
 		return nil, fmt.Errorf("erro ao decodificar gêneros: %v", err)
 	}
+	for i := range result.Genres {
+		result.Genres[i].MediaType = models.MediaTypeTV
+		result.Genres[i].Language = c.config.TMDB.Language
+	}
 	return result.Genres, nil
 }
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// translationsResponse cobre filmes e séries: o TMDB devolve o título em
+// data.title para filmes e em data.name para séries.
+type translationsResponse struct {
+	Translations []struct {
+		Language string `json:"iso_639_1"`
+		Country  string `json:"iso_3166_1"`
+		Data     struct {
+			Title    string `json:"title"`
+			Name     string `json:"name"`
+			Overview string `json:"overview"`
+		} `json:"data"`
+	} `json:"translations"`
+}
+
+// GetMovieTranslations busca os títulos e sinopses de um filme em todos os
+// idiomas disponíveis no TMDB.
+func (c *TMDBClient) GetMovieTranslations(movieID int) ([]models.Translation, error) {
+	return c.getTranslations(fmt.Sprintf("/movie/%d/translations", movieID))
+}
+
+// GetTVShowTranslations busca os nomes e sinopses de uma série em todos os
+// idiomas disponíveis no TMDB.
+func (c *TMDBClient) GetTVShowTranslations(showID int) ([]models.Translation, error) {
+	return c.getTranslations(fmt.Sprintf("/tv/%d/translations", showID))
+}
+
+func (c *TMDBClient) getTranslations(path string) ([]models.Translation, error) {
+	var resp translationsResponse
+	if err := c.getJSON(path, nil, &resp); err != nil {
+		return nil, err
+	}
+	translations := make([]models.Translation, 0, len(resp.Translations))
+	for _, t := range resp.Translations {
+		title := t.Data.Title
+		if title == "" {
+			title = t.Data.Name
+		}
+		translations = append(translations, models.Translation{
+			Language: t.Language,
+			Country:  t.Country,
+			Title:    title,
+			Overview: t.Data.Overview,
+		})
+	}
+	return translations, nil
+}
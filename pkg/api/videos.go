@@ -0,0 +1,170 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// VideoPolicy define quais vídeos são aceitos como trailer e em que ordem
+// de preferência eles são escolhidos.
+type VideoPolicy struct {
+	// Sites aceitos, em ordem de preferência (ex.: "YouTube", "Vimeo").
+	Sites []string
+	// Types aceitos, em ordem de prioridade (ex.: "Trailer", "Teaser", "Clip").
+	Types []string
+	// PreferOfficial coloca vídeos oficiais à frente dos demais do mesmo tipo.
+	PreferOfficial bool
+	// MinResolution descarta vídeos com resolução menor (ex.: 720). Zero aceita todos.
+	MinResolution int
+	// NewestFirst desempata pela data de publicação mais recente.
+	NewestFirst bool
+	// Languages em ordem de preferência, no formato "pt-BR" ou apenas "pt".
+	// Vídeos em outros idiomas não são aceitos; vazio aceita qualquer um.
+	Languages []string
+}
+
+// DefaultVideoPolicy reproduz o comportamento histórico da biblioteca:
+// apenas YouTube, Trailer > Teaser > Clip, oficiais primeiro e o idioma
+// configurado com fallback para en-US.
+func DefaultVideoPolicy(language string) VideoPolicy {
+	languages := []string{"en-US"}
+	if language != "" && language != "en-US" {
+		languages = []string{language, "en-US"}
+	}
+	return VideoPolicy{
+		Sites:          []string{"YouTube"},
+		Types:          []string{"Trailer", "Teaser", "Clip"},
+		PreferOfficial: true,
+		Languages:      languages,
+	}
+}
+
+// Allows informa se o vídeo atende aos filtros de site, tipo, resolução e
+// idioma.
+func (p VideoPolicy) Allows(v models.Video) bool {
+	if len(p.Languages) > 0 && p.languageRank(v) == len(p.Languages) {
+		return false
+	}
+	if len(p.Sites) > 0 && indexOf(p.Sites, v.Site) < 0 {
+		return false
+	}
+	if len(p.Types) > 0 && indexOf(p.Types, v.Type) < 0 {
+		return false
+	}
+	if p.MinResolution > 0 && v.Size < p.MinResolution {
+		return false
+	}
+	return true
+}
+
+// Sort ordena os vídeos pela preferência da política: idioma, tipo,
+// oficial, site, data de publicação e resolução. Nada é descartado:
+// idiomas, tipos e sites fora da política ficam por último.
+func (p VideoPolicy) Sort(videos []models.Video) {
+	sort.SliceStable(videos, func(i, j int) bool {
+		a, b := videos[i], videos[j]
+		if ra, rb := p.languageRank(a), p.languageRank(b); ra != rb {
+			return ra < rb
+		}
+		if ra, rb := rank(p.Types, a.Type), rank(p.Types, b.Type); ra != rb {
+			return ra < rb
+		}
+		if p.PreferOfficial && a.Official != b.Official {
+			return a.Official
+		}
+		if ra, rb := rank(p.Sites, a.Site), rank(p.Sites, b.Site); ra != rb {
+			return ra < rb
+		}
+		if p.NewestFirst && a.PublishedAt != b.PublishedAt {
+			return a.PublishedAt > b.PublishedAt
+		}
+		return a.Size > b.Size
+	})
+}
+
+// Select devolve o melhor vídeo permitido pela política.
+func (p VideoPolicy) Select(videos []models.Video) (models.Video, bool) {
+	var allowed []models.Video
+	for _, v := range videos {
+		if v.Key != "" && p.Allows(v) {
+			allowed = append(allowed, v)
+		}
+	}
+	if len(allowed) == 0 {
+		return models.Video{}, false
+	}
+	p.Sort(allowed)
+	return allowed[0], true
+}
+
+func (p VideoPolicy) languageRank(v models.Video) int {
+	// Primeiro procura idioma e país exatos, depois apenas o idioma.
+	for i, l := range p.Languages {
+		lang, country := splitLanguage(l)
+		if v.Language == lang && (country == "" || v.Country == country) {
+			return i
+		}
+	}
+	for i, l := range p.Languages {
+		if lang, _ := splitLanguage(l); v.Language == lang {
+			return i
+		}
+	}
+	return len(p.Languages)
+}
+
+// videoLanguages devolve os códigos ISO 639-1 da política, sem repetição,
+// no formato esperado por include_video_language.
+func (p VideoPolicy) videoLanguages() []string {
+	var codes []string
+	for _, l := range p.Languages {
+		lang, _ := splitLanguage(l)
+		if lang != "" && indexOf(codes, lang) < 0 {
+			codes = append(codes, lang)
+		}
+	}
+	return codes
+}
+
+func (c *TMDBClient) getVideos(mediaType string, id int) ([]models.Video, error) {
+	params := url.Values{}
+	params.Set("language", c.config.TMDB.Language)
+	if codes := c.videoPolicy.videoLanguages(); len(codes) > 0 {
+		params.Set("include_video_language", strings.Join(codes, ","))
+	}
+
+	var response VideoResponse
+	if err := c.getJSON(fmt.Sprintf("/%s/%d/videos", mediaType, id), params, &response); err != nil {
+		return nil, err
+	}
+
+	return c.prepareVideos(mediaType, id, response.Results), nil
+}
+
+func splitLanguage(tag string) (string, string) {
+	parts := strings.SplitN(tag, "-", 2)
+	if len(parts) == 2 {
+		return parts[0], parts[1]
+	}
+	return parts[0], ""
+}
+
+func indexOf(list []string, value string) int {
+	for i, v := range list {
+		if strings.EqualFold(v, value) {
+			return i
+		}
+	}
+	return -1
+}
+
+func rank(list []string, value string) int {
+	if i := indexOf(list, value); i >= 0 {
+		return i
+	}
+	return len(list)
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+func video(key, lang, typ string, official bool) models.Video {
+	return models.Video{Key: key, Site: "YouTube", Type: typ, Official: official, Language: lang, Size: 1080}
+}
+
+func TestVideoPolicySelect(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy VideoPolicy
+		videos []models.Video
+		want   string
+	}{
+		{"tipo antes de oficial", DefaultVideoPolicy("en-US"),
+			[]models.Video{video("teaser", "en", "Teaser", true), video("trailer", "en", "Trailer", false)}, "trailer"},
+		{"oficial primeiro", DefaultVideoPolicy("en-US"),
+			[]models.Video{video("fan", "en", "Trailer", false), video("oficial", "en", "Trailer", true)}, "oficial"},
+		{"sem preferência por oficial", VideoPolicy{Types: []string{"Trailer"}},
+			[]models.Video{video("primeiro", "en", "Trailer", false), video("oficial", "en", "Trailer", true)}, "primeiro"},
+		{"idioma antes do tipo", DefaultVideoPolicy("pt-BR"),
+			[]models.Video{video("en", "en", "Trailer", true), video("pt", "pt", "Teaser", false)}, "pt"},
+		{"fallback para en-US", DefaultVideoPolicy("pt-BR"),
+			[]models.Video{video("fr", "fr", "Trailer", true), video("en", "en", "Clip", false)}, "en"},
+		{"outros idiomas recusados", DefaultVideoPolicy("pt-BR"),
+			[]models.Video{video("fr", "fr", "Trailer", true)}, ""},
+		{"qualquer idioma sem Languages", VideoPolicy{Types: []string{"Trailer"}},
+			[]models.Video{video("fr", "fr", "Trailer", true)}, "fr"},
+		{"país exato antes do idioma", VideoPolicy{Languages: []string{"pt-BR", "pt"}},
+			[]models.Video{{Key: "pt", Language: "pt", Country: "PT"}, {Key: "br", Language: "pt", Country: "BR"}}, "br"},
+		{"site e tipo fora da política", DefaultVideoPolicy("en-US"),
+			[]models.Video{{Key: "vimeo", Site: "Vimeo", Type: "Trailer", Language: "en"}, video("bts", "en", "Behind the Scenes", true)}, ""},
+		{"sem chave", DefaultVideoPolicy("en-US"),
+			[]models.Video{video("", "en", "Trailer", true), video("teaser", "en", "Teaser", true)}, "teaser"},
+		{"resolução mínima", VideoPolicy{MinResolution: 1080},
+			[]models.Video{{Key: "720", Size: 720}, {Key: "1080", Size: 1080}}, "1080"},
+		{"mais recente primeiro", VideoPolicy{NewestFirst: true},
+			[]models.Video{{Key: "antigo", PublishedAt: "2020-01-01T00:00:00.000Z"}, {Key: "novo", PublishedAt: "2024-01-01T00:00:00.000Z"}}, "novo"},
+		{"maior resolução no empate", VideoPolicy{},
+			[]models.Video{{Key: "720", Size: 720}, {Key: "2160", Size: 2160}}, "2160"},
+		{"lista vazia", DefaultVideoPolicy("pt-BR"), nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.policy.Select(tt.videos)
+			if ok != (tt.want != "") || got.Key != tt.want {
+				t.Errorf("Select = %q, %v; esperado %q", got.Key, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestVideoPolicySort(t *testing.T) {
+	videos := []models.Video{
+		video("fr-trailer", "fr", "Trailer", true),
+		video("en-teaser", "en", "Teaser", true),
+		video("pt-clip", "pt", "Clip", true),
+		video("en-trailer", "en", "Trailer", false),
+		video("pt-trailer", "pt", "Trailer", false),
+		video("pt-trailer-oficial", "pt", "Trailer", true),
+		{Key: "pt-vimeo", Site: "Vimeo", Type: "Trailer", Language: "pt", Official: true},
+	}
+	DefaultVideoPolicy("pt-BR").Sort(videos)
+	var got []string
+	for _, v := range videos {
+		got = append(got, v.Key)
+	}
+	want := []string{"pt-trailer-oficial", "pt-vimeo", "pt-trailer", "pt-clip", "en-trailer", "en-teaser", "fr-trailer"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Sort = %v\nesperado %v", got, want)
+	}
+}
+
+func TestVideoLanguages(t *testing.T) {
+	policy := VideoPolicy{Languages: []string{"pt-BR", "pt-PT", "en-US", "pt"}}
+	if got, want := policy.videoLanguages(), []string{"pt", "en"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("videoLanguages = %v, esperado %v", got, want)
+	}
+}
@@ -0,0 +1,341 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/api"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+const (
+	defaultBatchSize   = 100
+	defaultConcurrency = 10
+)
+
+// Collector combina o cliente TMDB e o catálogo para tarefas que envolvem
+// os dois, como preencher depois os trailers de itens já salvos. O catálogo
+// pode ser um *database.Database ou, em testes, um database.MemoryStore.
+type Collector struct {
+	client *api.TMDBClient
+	db     database.Store
+
+	// BatchSize é a quantidade de IDs lidos do banco por vez. Zero usa 100.
+	BatchSize int
+	// Concurrency limita as requisições simultâneas ao TMDB. Zero usa 10.
+	Concurrency int
+}
+
+// BackfillResult conta os itens verificados e atualizados. Removed são os
+// itens que o TMDB respondeu com 404, marcados como
+// models.StatusNotFound em vez de entrarem em Errors.
+type BackfillResult struct {
+	Checked int
+	Updated int
+	Removed int
+	Errors  []api.EnrichmentError
+}
+
+// SyncOptions configura SyncMovies e SyncTVShows.
+type SyncOptions struct {
+	// Pages é o número de páginas de /discover lidas. Zero usa 1.
+	Pages int
+	// List define o enriquecimento de cada página.
+	List api.ListOptions
+	// Profile troca os filtros da seção fetch pelos de um perfil; ver
+	// SyncProfile.
+	Profile *config.Profile
+	// MarkMissing marca como models.StatusMissing os itens ativos do
+	// catálogo que não apareceram na sincronização. Só tem efeito quando
+	// todas as páginas foram lidas, e só faz sentido quando os filtros da
+	// seção fetch cobrem o catálogo inteiro. Não pode ser combinado com
+	// Profile, cujos filtros deixariam de fora itens ainda existentes.
+	MarkMissing bool
+}
+
+func (o SyncOptions) validate() error {
+	if o.MarkMissing && o.Profile != nil {
+		return errors.New("MarkMissing não pode ser usado com um perfil: os filtros do perfil não cobrem o catálogo inteiro")
+	}
+	return nil
+}
+
+// SyncResult resume uma sincronização. Removed conta os itens marcados
+// como removidos: os que o enriquecimento recebeu com 404 e, com
+// MarkMissing, os que não apareceram.
+type SyncResult struct {
+	Pages   int
+	Saved   database.SaveResult
+	Removed int
+	Errors  []api.EnrichmentError
+}
+
+func NewCollector(client *api.TMDBClient, db database.Store) *Collector {
+	return &Collector{client: client, db: db}
+}
+
+// BackfillMovieTrailers busca o trailer dos filmes salvos com trailer_url
+// vazio que ainda não foram consultados. Filmes sem trailer no TMDB ficam
+// registrados como consultados e não são buscados de novo. Falhas
+// individuais são devolvidas em Errors; o cancelamento de ctx interrompe o
+// backfill.
+func (c *Collector) BackfillMovieTrailers(ctx context.Context) (*BackfillResult, error) {
+	return c.backfill(ctx, c.db.MovieIDsWithoutTrailerContext, c.client.GetMovieTrailer,
+		c.db.UpdateMovieTrailerContext, c.db.SoftDeleteMoviesContext)
+}
+
+// BackfillTVShowTrailers segue as regras de BackfillMovieTrailers para as
+// séries.
+func (c *Collector) BackfillTVShowTrailers(ctx context.Context) (*BackfillResult, error) {
+	return c.backfill(ctx, c.db.TVShowIDsWithoutTrailerContext, c.client.GetTVShowTrailer,
+		c.db.UpdateTVShowTrailerContext, c.db.SoftDeleteTVShowsContext)
+}
+
+func (c *Collector) backfill(
+	ctx context.Context,
+	list func(ctx context.Context, afterID, limit int) ([]int, error),
+	fetch func(id int) (string, error),
+	update func(ctx context.Context, id int, trailerURL string) error,
+	remove func(ctx context.Context, ids []int, status string) (int, error),
+) (*BackfillResult, error) {
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	result := &BackfillResult{}
+	afterID := 0
+	for {
+		ids, err := list(ctx, afterID, batchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(ids) == 0 {
+			return result, nil
+		}
+		afterID = ids[len(ids)-1]
+
+		trailers := make([]string, len(ids))
+		errs := make([]error, len(ids))
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, concurrency)
+		for i, id := range ids {
+			wg.Add(1)
+			go func(i, id int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				trailers[i], errs[i] = fetch(id)
+				<-sem
+			}(i, id)
+		}
+		wg.Wait()
+
+		var notFound []int
+		for i, id := range ids {
+			result.Checked++
+			if api.IsNotFound(errs[i]) {
+				notFound = append(notFound, id)
+				continue
+			}
+			if errs[i] != nil {
+				result.Errors = append(result.Errors, api.EnrichmentError{ID: id, Err: errs[i]})
+				continue
+			}
+			// Trailer vazio também é gravado, para marcar o item como
+			// consultado.
+			if err := update(ctx, id, trailers[i]); err != nil {
+				return result, err
+			}
+			if trailers[i] != "" {
+				result.Updated++
+			}
+		}
+		removed, err := remove(ctx, notFound, models.StatusNotFound)
+		if err != nil {
+			return result, err
+		}
+		result.Removed += removed
+	}
+}
+
+// SyncMovies lê as páginas de /discover/movie, grava os filmes e acrescenta
+// o retrato do dia de popularidade e notas em movie_metrics. Filmes que o
+// enriquecimento recebe com 404 não são gravados e são marcados como
+// models.StatusNotFound; as demais falhas de enriquecimento são devolvidas
+// em Errors. Cada página é gravada em uma única transação. Erros de
+// listagem ou gravação interrompem a sincronização.
+func (c *Collector) SyncMovies(ctx context.Context, opts SyncOptions) (*SyncResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+	now := time.Now()
+	var seen []int
+	complete := false
+	for page := 1; page <= syncPages(opts); page++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		list, err := c.discoverMovies(page, opts)
+		if err != nil {
+			return result, err
+		}
+		notFound, errs := splitNotFound(list.Errors)
+		movies := withoutIDs(list.Movies, notFound, func(m models.Movie) int { return m.ID })
+		var saved database.SaveResult
+		var removed int
+		err = c.db.InTx(ctx, func(tx database.TxStore) error {
+			var err error
+			if saved, err = tx.SaveMoviesBulkWithResult(movies); err != nil {
+				return err
+			}
+			if err := tx.SaveMovieMetrics(now, movies); err != nil {
+				return err
+			}
+			removed, err = tx.SoftDeleteMovies(notFound, models.StatusNotFound)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+		result.Saved.Add(saved)
+		result.Removed += removed
+		result.Errors = append(result.Errors, errs...)
+		for _, item := range movies {
+			seen = append(seen, item.ID)
+		}
+		if page >= list.TotalPages {
+			complete = true
+			break
+		}
+	}
+	if opts.MarkMissing && complete {
+		removed, err := c.db.MarkMissingMoviesContext(ctx, seen)
+		if err != nil {
+			return result, err
+		}
+		result.Removed += removed
+	}
+	return result, nil
+}
+
+// SyncTVShows segue as regras de SyncMovies para /discover/tv e
+// tvshow_metrics.
+func (c *Collector) SyncTVShows(ctx context.Context, opts SyncOptions) (*SyncResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	result := &SyncResult{}
+	now := time.Now()
+	var seen []int
+	complete := false
+	for page := 1; page <= syncPages(opts); page++ {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		list, err := c.discoverTVShows(page, opts)
+		if err != nil {
+			return result, err
+		}
+		notFound, errs := splitNotFound(list.Errors)
+		shows := withoutIDs(list.Shows, notFound, func(s models.TVShow) int { return s.ID })
+		var saved database.SaveResult
+		var removed int
+		err = c.db.InTx(ctx, func(tx database.TxStore) error {
+			var err error
+			if saved, err = tx.SaveTVShowsBulkWithResult(shows); err != nil {
+				return err
+			}
+			if err := tx.SaveTVShowMetrics(now, shows); err != nil {
+				return err
+			}
+			removed, err = tx.SoftDeleteTVShows(notFound, models.StatusNotFound)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+		result.Pages++
+		result.Saved.Add(saved)
+		result.Removed += removed
+		result.Errors = append(result.Errors, errs...)
+		for _, item := range shows {
+			seen = append(seen, item.ID)
+		}
+		if page >= list.TotalPages {
+			complete = true
+			break
+		}
+	}
+	if opts.MarkMissing && complete {
+		removed, err := c.db.MarkMissingTVShowsContext(ctx, seen)
+		if err != nil {
+			return result, err
+		}
+		result.Removed += removed
+	}
+	return result, nil
+}
+
+func (c *Collector) discoverMovies(page int, opts SyncOptions) (*api.MovieResults, error) {
+	if opts.Profile != nil {
+		return c.client.DiscoverMoviesWithProfile(page, *opts.Profile, opts.List)
+	}
+	return c.client.DiscoverMoviesWithOptions(page, opts.List)
+}
+
+func (c *Collector) discoverTVShows(page int, opts SyncOptions) (*api.TVShowResults, error) {
+	if opts.Profile != nil {
+		return c.client.DiscoverTVShowsWithProfile(page, *opts.Profile, opts.List)
+	}
+	return c.client.DiscoverTVShowsWithOptions(page, opts.List)
+}
+
+func syncPages(opts SyncOptions) int {
+	if opts.Pages <= 0 {
+		return 1
+	}
+	return opts.Pages
+}
+
+// splitNotFound separa os itens que o TMDB respondeu com 404 das demais
+// falhas de enriquecimento.
+func splitNotFound(errs []api.EnrichmentError) ([]int, []api.EnrichmentError) {
+	var ids []int
+	var rest []api.EnrichmentError
+	for _, e := range errs {
+		if api.IsNotFound(e.Err) {
+			ids = append(ids, e.ID)
+		} else {
+			rest = append(rest, e)
+		}
+	}
+	return ids, rest
+}
+
+// withoutIDs devolve os itens cujo ID não está em ids, na ordem original.
+func withoutIDs[T any](items []T, ids []int, id func(T) int) []T {
+	if len(ids) == 0 {
+		return items
+	}
+	skip := make(map[int]bool, len(ids))
+	for _, i := range ids {
+		skip[i] = true
+	}
+	kept := make([]T, 0, len(items))
+	for _, item := range items {
+		if !skip[id(item)] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
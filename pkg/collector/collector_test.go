@@ -0,0 +1,120 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/api"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// TestSyncMoviesSkipsNotFound confere que um filme que o enriquecimento
+// recebe com 404 não é regravado (o que o reativaria) antes de ser marcado
+// como removido.
+func TestSyncMoviesSkipsNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/discover/movie":
+			w.Write([]byte(`{"page": 1, "total_pages": 1, "total_results": 2,
+				"results": [{"id": 1, "title": "Um"}, {"id": 2, "title": "Dois"}]}`))
+		case "/movie/1/videos":
+			w.Write([]byte(`{"results": []}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.TMDB.APIKey = "chave"
+	cfg.TMDB.BaseURL = srv.URL
+
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := store.SaveMovieContext(ctx, &models.Movie{ID: 2, Title: "Dois"}); err != nil {
+		t.Fatalf("SaveMovieContext: %v", err)
+	}
+	if _, err := store.SoftDeleteMoviesContext(ctx, []int{2}, models.StatusNotFound); err != nil {
+		t.Fatalf("SoftDeleteMoviesContext: %v", err)
+	}
+
+	c := NewCollector(api.NewTMDBClient(cfg), store)
+	result, err := c.SyncMovies(ctx, SyncOptions{List: api.ListOptions{Enrichment: api.EnrichTrailers}})
+	if err != nil {
+		t.Fatalf("SyncMovies: %v", err)
+	}
+	if result.Saved != (database.SaveResult{Inserted: 1}) {
+		t.Errorf("Saved = %+v, esperado só o filme 1 inserido", result.Saved)
+	}
+	// O filme 2 já estava removido e não foi reativado.
+	if result.Removed != 0 || len(result.Errors) != 0 {
+		t.Errorf("Removed = %d, Errors = %v", result.Removed, result.Errors)
+	}
+	movie, err := store.GetMovieContext(ctx, 2)
+	if err != nil {
+		t.Fatalf("GetMovieContext: %v", err)
+	}
+	if movie.Status != models.StatusNotFound || movie.DeletedAt == nil {
+		t.Errorf("filme 2: Status = %q, DeletedAt = %v", movie.Status, movie.DeletedAt)
+	}
+}
+
+// TestSyncMarkMissing confere que MarkMissing é recusado com os
+// filtros de um perfil, que deixariam de fora itens ainda existentes, e
+// que sem perfil só os itens ausentes da coleta completa são marcados.
+func TestSyncMarkMissing(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"page": 1, "total_pages": 1, "total_results": 1, "results": [{"id": 1, "title": "Um", "name": "Um"}]}`))
+	}))
+	defer srv.Close()
+
+	cfg := config.Default()
+	cfg.TMDB.APIKey = "chave"
+	cfg.TMDB.BaseURL = srv.URL
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	if err := store.SaveMoviesBulkContext(ctx, []models.Movie{{ID: 1, Title: "Um"}, {ID: 2, Title: "Dois"}}); err != nil {
+		t.Fatalf("SaveMoviesBulkContext: %v", err)
+	}
+	if err := store.SaveTVShowsBulkContext(ctx, []models.TVShow{{ID: 1, Name: "Um"}, {ID: 2, Name: "Dois"}}); err != nil {
+		t.Fatalf("SaveTVShowsBulkContext: %v", err)
+	}
+	c := NewCollector(api.NewTMDBClient(cfg), store)
+
+	profile := cfg.FetchProfile(models.MediaTypeMovie)
+	profile.OriginalLanguages = []string{"pt"}
+	if _, err := c.SyncMovies(ctx, SyncOptions{Profile: &profile, MarkMissing: true}); err == nil {
+		t.Error("SyncMovies aceitou MarkMissing com um perfil")
+	}
+	profile.MediaType = models.MediaTypeTV
+	if _, err := c.SyncTVShows(ctx, SyncOptions{Profile: &profile, MarkMissing: true}); err == nil {
+		t.Error("SyncTVShows aceitou MarkMissing com um perfil")
+	}
+	if requests != 0 {
+		t.Errorf("%d requisição(ões) ao TMDB antes de recusar", requests)
+	}
+	movie, err := store.GetMovieContext(ctx, 2)
+	if err != nil || movie.Status != models.StatusActive {
+		t.Errorf("filme 2 depois da recusa = %+v, %v, esperado ativo", movie, err)
+	}
+
+	result, err := c.SyncMovies(ctx, SyncOptions{MarkMissing: true})
+	if err != nil {
+		t.Fatalf("SyncMovies: %v", err)
+	}
+	if result.Removed != 1 {
+		t.Errorf("Removed = %d, esperado 1", result.Removed)
+	}
+	if movie, err := store.GetMovieContext(ctx, 2); err != nil || movie.Status != models.StatusMissing {
+		t.Errorf("filme 2 = %+v, %v, esperado %q", movie, err, models.StatusMissing)
+	}
+	if movie, err := store.GetMovieContext(ctx, 1); err != nil || movie.Status != models.StatusActive {
+		t.Errorf("filme 1 = %+v, %v, esperado ativo", movie, err)
+	}
+}
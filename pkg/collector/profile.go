@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/api"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// ProfileResult é o resultado da sincronização de um perfil.
+type ProfileResult struct {
+	Name string
+	*SyncResult
+}
+
+// SyncProfile executa o perfil name da configuração do cliente: lê as
+// páginas de /discover com os filtros do perfil e grava os itens como
+// SyncMovies ou SyncTVShows. As mudanças auditadas recebem o nome do perfil
+// como rotina; ver database.WithSyncJob.
+func (c *Collector) SyncProfile(ctx context.Context, name string) (*SyncResult, error) {
+	profile, err := c.client.Config().Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	list, err := api.ProfileListOptions(profile)
+	if err != nil {
+		return nil, err
+	}
+	ctx = database.WithSyncJob(ctx, name)
+	opts := SyncOptions{Pages: profile.Pages, List: list, Profile: &profile}
+	switch profile.MediaType {
+	case models.MediaTypeMovie:
+		return c.SyncMovies(ctx, opts)
+	case models.MediaTypeTV:
+		return c.SyncTVShows(ctx, opts)
+	}
+	return nil, fmt.Errorf("tipo de mídia inválido: %q", profile.MediaType)
+}
+
+// SyncProfiles executa os perfis names, em ordem, ou todos os perfis da
+// configuração quando names é vazio. Para no primeiro erro e devolve os
+// resultados dos perfis já executados.
+func (c *Collector) SyncProfiles(ctx context.Context, names ...string) ([]ProfileResult, error) {
+	if len(names) == 0 {
+		names = c.client.Config().ProfileNames()
+	}
+	results := make([]ProfileResult, 0, len(names))
+	for _, name := range names {
+		result, err := c.SyncProfile(ctx, name)
+		if result != nil {
+			results = append(results, ProfileResult{Name: name, SyncResult: result})
+		}
+		if err != nil {
+			return results, fmt.Errorf("perfil %s: %v", name, err)
+		}
+	}
+	return results, nil
+}
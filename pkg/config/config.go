@@ -1,25 +1,62 @@
 package config
 
 type SortConfig struct {
-	Field     string `json:"field"`
-	Direction string `json:"direction"`
+	Field     string `json:"field" yaml:"field" toml:"field"`
+	Direction string `json:"direction" yaml:"direction" toml:"direction"`
+}
+
+// DatabaseConfig descreve o banco aberto por database.NewDatabaseFromConfig.
+type DatabaseConfig struct {
+	// Driver é o nome registrado em database/sql: "sqlite3" (padrão),
+	// "sqlite" (modernc.org/sqlite), "postgres", "pgx" ou "mysql"; ver
+	// DBDrivers. Drivers diferentes de sqlite3 precisam ser importados pelo
+	// programa.
+	Driver string `json:"driver" yaml:"driver" toml:"driver"`
+	// DSN é a string de conexão do driver. Com sqlite3 e sqlite, Filename
+	// pode ser usado no lugar.
+	DSN      string `json:"dsn" yaml:"dsn" toml:"dsn"`
+	Filename string `json:"filename" yaml:"filename" toml:"filename"`
+	// MaxOpenConns e MaxIdleConns limitam o pool de conexões. Zero mantém
+	// o padrão de database/sql.
+	MaxOpenConns int `json:"max_open_conns" yaml:"max_open_conns" toml:"max_open_conns"`
+	MaxIdleConns int `json:"max_idle_conns" yaml:"max_idle_conns" toml:"max_idle_conns"`
+	// BusyTimeout (ex.: "5s") e WAL valem apenas para sqlite3 e sqlite.
+	BusyTimeout string `json:"busy_timeout" yaml:"busy_timeout" toml:"busy_timeout"`
+	WAL         bool   `json:"wal" yaml:"wal" toml:"wal"`
+	TablePrefix string `json:"table_prefix" yaml:"table_prefix" toml:"table_prefix"`
+	AutoMigrate bool   `json:"auto_migrate" yaml:"auto_migrate" toml:"auto_migrate"`
 }
 
 type Config struct {
 	TMDB struct {
-		APIKey       string `json:"api_key"`
-		BaseURL      string `json:"base_url"`
-		ImageBaseURL string `json:"image_base_url"`
-		Language     string `json:"language"`
-	} `json:"tmdb"`
+		APIKey  string `json:"api_key" yaml:"api_key" toml:"api_key"`
+		BaseURL string `json:"base_url" yaml:"base_url" toml:"base_url"`
+		// Obsoleto: os modelos guardam o caminho bruto da imagem e as URLs
+		// são montadas com PosterURL/BackdropURL a partir da configuração
+		// de /configuration (ver api.TMDBClient.ImageConfig).
+		ImageBaseURL string `json:"image_base_url" yaml:"image_base_url" toml:"image_base_url"`
+		Language     string `json:"language" yaml:"language" toml:"language"`
+		// ConfigCacheTTL é o tempo de cache das respostas de /configuration,
+		// no formato de time.ParseDuration (ex.: "24h"). Vazio usa 24h.
+		ConfigCacheTTL string `json:"config_cache_ttl" yaml:"config_cache_ttl" toml:"config_cache_ttl"`
+	} `json:"tmdb" yaml:"tmdb" toml:"tmdb"`
 	Fetch struct {
-		NumPages       int    `json:"num_pages"`
-		IncludeAdult   bool   `json:"include_adult"`
-		IncludeVideo   bool   `json:"include_video"`
-		MaxReleaseDate string `json:"max_release_date"`
+		NumPages     int  `json:"num_pages" yaml:"num_pages" toml:"num_pages"`
+		IncludeAdult bool `json:"include_adult" yaml:"include_adult" toml:"include_adult"`
+		IncludeVideo bool `json:"include_video" yaml:"include_video" toml:"include_video"`
+		// MinReleaseDate e MaxReleaseDate limitam a data de lançamento (ou
+		// de estreia, para séries) em /discover. Aceitam datas fixas ou
+		// expressões como "today", "today+30d" e "-1y", resolvidas a cada
+		// requisição; ver ResolveDate. Vazio não filtra.
+		MinReleaseDate string `json:"min_release_date" yaml:"min_release_date" toml:"min_release_date"`
+		MaxReleaseDate string `json:"max_release_date" yaml:"max_release_date" toml:"max_release_date"`
 		Sort           struct {
-			Movies  SortConfig `json:"movies"`
-			TVShows SortConfig `json:"tv_shows"`
-		} `json:"sort"`
-	} `json:"fetch"`
+			Movies  SortConfig `json:"movies" yaml:"movies" toml:"movies"`
+			TVShows SortConfig `json:"tv_shows" yaml:"tv_shows" toml:"tv_shows"`
+		} `json:"sort" yaml:"sort" toml:"sort"`
+	} `json:"fetch" yaml:"fetch" toml:"fetch"`
+	Database DatabaseConfig `json:"database" yaml:"database" toml:"database"`
+	// Profiles são coletas nomeadas, executadas por
+	// collector.SyncProfile e SyncProfiles.
+	Profiles []Profile `json:"profiles" yaml:"profiles" toml:"profiles"`
 }
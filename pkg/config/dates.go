@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateTerm é um deslocamento como "+30d" ou "-1y".
+var dateTerm = regexp.MustCompile(`^([+-])(\d+)([dwmy])`)
+
+// ResolveDate converte o valor de um campo de data da configuração
+// (fetch.max_release_date, fetch.min_release_date) na data AAAA-MM-DD
+// enviada ao TMDB, relativa a now. Aceita:
+//
+//   - uma data fixa: "2024-12-31";
+//   - "today", opcionalmente seguido de deslocamentos: "today+30d";
+//   - apenas deslocamentos, relativos a hoje: "-1y", "+2w-3d".
+//
+// As unidades são d (dias), w (semanas), m (meses) e y (anos). Um valor
+// vazio devolve "", e o parâmetro correspondente não é enviado.
+func ResolveDate(expr string, now time.Time) (string, error) {
+	value := strings.ToLower(strings.TrimSpace(expr))
+	if value == "" {
+		return "", nil
+	}
+	if t, err := time.Parse(DateLayout, value); err == nil {
+		return t.Format(DateLayout), nil
+	}
+
+	rest := strings.TrimPrefix(value, "today")
+	date := now
+	for rest != "" {
+		m := dateTerm.FindStringSubmatch(rest)
+		if m == nil {
+			return "", fmt.Errorf("data inválida: %q", expr)
+		}
+		n, err := strconv.Atoi(m[2])
+		if err != nil {
+			return "", fmt.Errorf("data inválida: %q", expr)
+		}
+		if m[1] == "-" {
+			n = -n
+		}
+		switch m[3] {
+		case "d":
+			date = date.AddDate(0, 0, n)
+		case "w":
+			date = date.AddDate(0, 0, 7*n)
+		case "m":
+			date = date.AddDate(0, n, 0)
+		case "y":
+			date = date.AddDate(n, 0, 0)
+		}
+		rest = rest[len(m[0]):]
+	}
+	return date.Format(DateLayout), nil
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveDate(t *testing.T) {
+	now := time.Date(2024, 3, 15, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr    string
+		want    string
+		wantErr bool
+	}{
+		{expr: "", want: ""},
+		{expr: "  ", want: ""},
+		{expr: "today", want: "2024-03-15"},
+		{expr: " Today ", want: "2024-03-15"},
+		{expr: "today-10d", want: "2024-03-05"},
+		{expr: "today+30d", want: "2024-04-14"},
+		{expr: "today-2w", want: "2024-03-01"},
+		{expr: "today+1m", want: "2024-04-15"},
+		{expr: "today-1y", want: "2023-03-15"},
+		{expr: "-1y", want: "2023-03-15"},
+		{expr: "+2w-3d", want: "2024-03-26"},
+		{expr: "2024-12-31", want: "2024-12-31"},
+		{expr: "2000-02-29", want: "2000-02-29"},
+		{expr: "2024-13-01", wantErr: true},
+		{expr: "2023-02-29", wantErr: true},
+		{expr: "31/12/2024", wantErr: true},
+		{expr: "ontem", wantErr: true},
+		{expr: "todayish", wantErr: true},
+		{expr: "today-", wantErr: true},
+		{expr: "today-10", wantErr: true},
+		{expr: "today-10h", wantErr: true},
+		{expr: "today 10d", wantErr: true},
+		{expr: "10d", wantErr: true},
+		{expr: "today-10d-", wantErr: true},
+		{expr: "today-99999999999999999999d", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ResolveDate(tt.expr, now)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ResolveDate(%q) = %q, esperado erro", tt.expr, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ResolveDate(%q) = %q, %v, esperado %q", tt.expr, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Formatos de arquivo aceitos por Load e Save, escolhidos pela extensão.
+// Os três usam os mesmos nomes de campos.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// FormatOf devolve o formato do arquivo pela extensão: .json, .yaml, .yml
+// ou .toml.
+func FormatOf(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	}
+	return "", fmt.Errorf("formato de configuração não suportado: %q (use .json, .yaml, .yml ou .toml)", path)
+}
+
+// Decode lê data no formato informado sobre os valores já presentes em
+// cfg; campos ausentes no arquivo mantêm o valor anterior. Chaves
+// desconhecidas são rejeitadas nos três formatos, inclusive as que só
+// diferem de um campo em maiúsculas e minúsculas (o JSON as aceitaria).
+func Decode(data []byte, format string, cfg *Config) error {
+	var raw map[string]interface{}
+	if err := decode(data, format, &raw); err != nil {
+		return err
+	}
+	var unknown []string
+	unknownKeys(raw, reflect.TypeOf(cfg).Elem(), format, "", &unknown)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("campos desconhecidos: %s", strings.Join(unknown, ", "))
+	}
+	return decode(data, format, cfg)
+}
+
+func decode(data []byte, format string, v interface{}) error {
+	switch format {
+	case FormatJSON:
+		return json.Unmarshal(data, v)
+	case FormatYAML:
+		return yaml.Unmarshal(data, v)
+	case FormatTOML:
+		_, err := toml.Decode(string(data), v)
+		return err
+	}
+	return fmt.Errorf("formato de configuração desconhecido: %q", format)
+}
+
+// unknownKeys acrescenta a unknown o caminho (ex.: "profiles[0].pgaes")
+// das chaves de raw sem campo correspondente em t, comparando com a tag do
+// formato. Desce em structs e em listas de structs, como Profiles.
+func unknownKeys(raw interface{}, t reflect.Type, format, path string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := raw.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := make(map[string]reflect.Type, t.NumField())
+		for i := 0; i < t.NumField(); i++ {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get(format), ",")
+			fields[name] = t.Field(i).Type
+		}
+		for key, value := range m {
+			field, ok := fields[key]
+			if !ok {
+				*unknown = append(*unknown, path+key)
+				continue
+			}
+			unknownKeys(value, field, format, path+key+".", unknown)
+		}
+	case reflect.Slice:
+		// O TOML devolve listas de tabelas como []map[string]interface{};
+		// os outros formatos, como []interface{}.
+		v := reflect.ValueOf(raw)
+		if v.Kind() != reflect.Slice {
+			return
+		}
+		prefix := strings.TrimSuffix(path, ".")
+		for i := 0; i < v.Len(); i++ {
+			unknownKeys(v.Index(i).Interface(), t.Elem(), format, fmt.Sprintf("%s[%d].", prefix, i), unknown)
+		}
+	}
+}
+
+// Encode escreve cfg no formato informado.
+func Encode(cfg *Config, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(cfg, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	case FormatYAML:
+		return yaml.Marshal(cfg)
+	case FormatTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(cfg); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("formato de configuração desconhecido: %q", format)
+}
+
+// Save grava cfg em path, no formato indicado pela extensão. Um arquivo
+// gravado por Save e lido por Load devolve a mesma configuração.
+func (c *Config) Save(path string) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	data, err := Encode(c, format)
+	if err != nil {
+		return fmt.Errorf("erro ao codificar configuração: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("erro ao gravar configuração: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fullConfig devolve uma configuração válida com todos os campos
+// preenchidos, para que o round-trip não dependa dos valores padrão.
+func fullConfig() *Config {
+	yes, no := true, false
+	cfg := &Config{}
+	cfg.TMDB.APIKey = "chave"
+	cfg.TMDB.BaseURL = "https://api.themoviedb.org/3"
+	cfg.TMDB.ImageBaseURL = "https://image.tmdb.org/t/p/w500"
+	cfg.TMDB.Language = "pt-BR"
+	cfg.TMDB.ConfigCacheTTL = "12h"
+	cfg.Fetch.NumPages = 3
+	cfg.Fetch.IncludeAdult = true
+	cfg.Fetch.IncludeVideo = true
+	cfg.Fetch.MinReleaseDate = "-1y"
+	cfg.Fetch.MaxReleaseDate = "today+30d"
+	cfg.Fetch.Sort.Movies = SortConfig{Field: "vote_average", Direction: "asc"}
+	cfg.Fetch.Sort.TVShows = SortConfig{Field: "first_air_date", Direction: "desc"}
+	cfg.Database = DatabaseConfig{
+		Driver:       "sqlite3",
+		DSN:          "file:catalogo.db",
+		Filename:     "catalogo.db",
+		MaxOpenConns: 4,
+		MaxIdleConns: 2,
+		BusyTimeout:  "5s",
+		WAL:          true,
+		TablePrefix:  "catalogo_",
+		AutoMigrate:  true,
+	}
+	cfg.Profiles = []Profile{
+		{
+			Name:              "lancamentos-br",
+			MediaType:         "movie",
+			Language:          "pt-BR",
+			OriginalLanguages: []string{"pt"},
+			OriginCountries:   []string{"BR"},
+			Region:            "BR",
+			Genres:            []int{18, 35},
+			MinVoteAverage:    6.5,
+			MinVoteCount:      50,
+			MinReleaseDate:    "today-30d",
+			MaxReleaseDate:    "today",
+			IncludeAdult:      &no,
+			IncludeVideo:      &yes,
+			Sort:              SortConfig{Field: "release_date", Direction: "desc"},
+			Pages:             2,
+			Enrichment:        "trailers",
+			Concurrency:       5,
+		},
+		{
+			Name:              "series-coreanas",
+			MediaType:         "tv",
+			Language:          "en-US",
+			OriginalLanguages: []string{"ko"},
+			OriginCountries:   []string{"KR"},
+			Genres:            []int{10765},
+			MinVoteAverage:    7,
+			MinVoteCount:      100,
+			MinReleaseDate:    "2020-01-01",
+			MaxReleaseDate:    "2024-12-31",
+			IncludeAdult:      &no,
+			IncludeVideo:      &no,
+			Sort:              SortConfig{Field: "popularity", Direction: "desc"},
+			Pages:             1,
+			Enrichment:        "details",
+			Concurrency:       2,
+		},
+	}
+	return cfg
+}
+
+// clearEnv esvazia as variáveis TMDB_*, que Load aplicaria sobre o
+// arquivo.
+func clearEnv(t *testing.T) {
+	for _, v := range envVars(&Config{}) {
+		t.Setenv(v.name, "")
+	}
+}
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	clearEnv(t)
+	for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+		t.Run(ext, func(t *testing.T) {
+			want := fullConfig()
+			if err := want.Validate(); err != nil {
+				t.Fatalf("configuração de teste inválida: %v", err)
+			}
+			path := filepath.Join(t.TempDir(), "config"+ext)
+			if err := want.Save(path); err != nil {
+				t.Fatalf("Save: %v", err)
+			}
+			got, err := Load(path)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Load(Save(cfg)) difere do original:\n got: %+v\nwant: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestDecodeRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{FormatJSON, `{"tmdb": {"api_key": "chave", "Language": "pt-BR"}, "profiles": [{"name": "a", "pgaes": 2}]}`},
+		{FormatYAML, "tmdb:\n  api_key: chave\n  Language: pt-BR\nprofiles:\n  - name: a\n    pgaes: 2\n"},
+		{FormatTOML, "[tmdb]\napi_key = \"chave\"\nLanguage = \"pt-BR\"\n\n[[profiles]]\nname = \"a\"\npgaes = 2\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			err := Decode([]byte(tt.data), tt.format, Default())
+			if err == nil {
+				t.Fatal("Decode aceitou chaves desconhecidas")
+			}
+			for _, key := range []string{"tmdb.Language", "profiles[0].pgaes"} {
+				if !strings.Contains(err.Error(), key) {
+					t.Errorf("erro %q não cita %s", err, key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Valores usados por Default e, portanto, por Load quando o campo não está
+// no arquivo nem no ambiente.
+const (
+	DefaultBaseURL        = "https://api.themoviedb.org/3"
+	DefaultLanguage       = "en-US"
+	DefaultConfigCacheTTL = "24h"
+	DefaultNumPages       = 1
+	DefaultSortField      = "popularity"
+	DefaultSortDirection  = "desc"
+	DefaultDBDriver       = "sqlite3"
+	DefaultDBFilename     = "media.db"
+)
+
+// Default devolve a configuração usada como base por Load. Só a API key
+// não tem valor padrão.
+func Default() *Config {
+	cfg := &Config{}
+	cfg.TMDB.BaseURL = DefaultBaseURL
+	cfg.TMDB.Language = DefaultLanguage
+	cfg.TMDB.ConfigCacheTTL = DefaultConfigCacheTTL
+	cfg.Fetch.NumPages = DefaultNumPages
+	cfg.Fetch.Sort.Movies = SortConfig{Field: DefaultSortField, Direction: DefaultSortDirection}
+	cfg.Fetch.Sort.TVShows = SortConfig{Field: DefaultSortField, Direction: DefaultSortDirection}
+	cfg.Database.Driver = DefaultDBDriver
+	cfg.Database.Filename = DefaultDBFilename
+	return cfg
+}
+
+// Load lê a configuração do arquivo em path sobre os valores de Default,
+// aplica as variáveis de ambiente TMDB_* (ver ApplyEnv) e valida o
+// resultado. O formato (JSON, YAML ou TOML) vem da extensão; ver FormatOf.
+// Com path vazio, apenas os padrões e o ambiente são usados.
+//
+// Erros de validação e de variáveis de ambiente são devolvidos juntos, como
+// ValidationErrors.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path != "" {
+		format, err := FormatOf(path)
+		if err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler configuração: %v", err)
+		}
+		if err := Decode(data, format, cfg); err != nil {
+			return nil, fmt.Errorf("erro ao decodificar %s: %v", path, err)
+		}
+	}
+	errs := ApplyEnv(cfg)
+	errs = append(errs, cfg.validate()...)
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// envVar liga uma variável de ambiente ao campo que ela substitui.
+type envVar struct {
+	name  string
+	field string
+	value interface{}
+}
+
+func envVars(cfg *Config) []envVar {
+	return []envVar{
+		{"TMDB_API_KEY", "tmdb.api_key", &cfg.TMDB.APIKey},
+		{"TMDB_BASE_URL", "tmdb.base_url", &cfg.TMDB.BaseURL},
+		{"TMDB_IMAGE_BASE_URL", "tmdb.image_base_url", &cfg.TMDB.ImageBaseURL},
+		{"TMDB_LANGUAGE", "tmdb.language", &cfg.TMDB.Language},
+		{"TMDB_CONFIG_CACHE_TTL", "tmdb.config_cache_ttl", &cfg.TMDB.ConfigCacheTTL},
+		{"TMDB_NUM_PAGES", "fetch.num_pages", &cfg.Fetch.NumPages},
+		{"TMDB_INCLUDE_ADULT", "fetch.include_adult", &cfg.Fetch.IncludeAdult},
+		{"TMDB_INCLUDE_VIDEO", "fetch.include_video", &cfg.Fetch.IncludeVideo},
+		{"TMDB_MIN_RELEASE_DATE", "fetch.min_release_date", &cfg.Fetch.MinReleaseDate},
+		{"TMDB_MAX_RELEASE_DATE", "fetch.max_release_date", &cfg.Fetch.MaxReleaseDate},
+		{"TMDB_SORT_MOVIES_FIELD", "fetch.sort.movies.field", &cfg.Fetch.Sort.Movies.Field},
+		{"TMDB_SORT_MOVIES_DIRECTION", "fetch.sort.movies.direction", &cfg.Fetch.Sort.Movies.Direction},
+		{"TMDB_SORT_TV_SHOWS_FIELD", "fetch.sort.tv_shows.field", &cfg.Fetch.Sort.TVShows.Field},
+		{"TMDB_SORT_TV_SHOWS_DIRECTION", "fetch.sort.tv_shows.direction", &cfg.Fetch.Sort.TVShows.Direction},
+		{"TMDB_DB_DRIVER", "database.driver", &cfg.Database.Driver},
+		{"TMDB_DB_DSN", "database.dsn", &cfg.Database.DSN},
+		{"TMDB_DB_FILENAME", "database.filename", &cfg.Database.Filename},
+		{"TMDB_DB_MAX_OPEN_CONNS", "database.max_open_conns", &cfg.Database.MaxOpenConns},
+		{"TMDB_DB_MAX_IDLE_CONNS", "database.max_idle_conns", &cfg.Database.MaxIdleConns},
+		{"TMDB_DB_BUSY_TIMEOUT", "database.busy_timeout", &cfg.Database.BusyTimeout},
+		{"TMDB_DB_WAL", "database.wal", &cfg.Database.WAL},
+		{"TMDB_DB_TABLE_PREFIX", "database.table_prefix", &cfg.Database.TablePrefix},
+		{"TMDB_DB_AUTO_MIGRATE", "database.auto_migrate", &cfg.Database.AutoMigrate},
+	}
+}
+
+// ApplyEnv substitui os campos de cfg pelas variáveis de ambiente definidas:
+// TMDB_API_KEY, TMDB_BASE_URL, TMDB_IMAGE_BASE_URL, TMDB_LANGUAGE,
+// TMDB_CONFIG_CACHE_TTL, TMDB_NUM_PAGES, TMDB_INCLUDE_ADULT,
+// TMDB_INCLUDE_VIDEO, TMDB_MIN_RELEASE_DATE, TMDB_MAX_RELEASE_DATE,
+// TMDB_SORT_MOVIES_FIELD, TMDB_SORT_MOVIES_DIRECTION,
+// TMDB_SORT_TV_SHOWS_FIELD, TMDB_SORT_TV_SHOWS_DIRECTION e, para a seção
+// database, TMDB_DB_DRIVER, TMDB_DB_DSN, TMDB_DB_FILENAME,
+// TMDB_DB_MAX_OPEN_CONNS, TMDB_DB_MAX_IDLE_CONNS, TMDB_DB_BUSY_TIMEOUT,
+// TMDB_DB_WAL, TMDB_DB_TABLE_PREFIX e TMDB_DB_AUTO_MIGRATE. Variáveis
+// vazias são ignoradas. Devolve os valores que não puderam ser convertidos.
+func ApplyEnv(cfg *Config) ValidationErrors {
+	var errs ValidationErrors
+	for _, v := range envVars(cfg) {
+		raw, ok := os.LookupEnv(v.name)
+		if !ok || raw == "" {
+			continue
+		}
+		switch p := v.value.(type) {
+		case *string:
+			*p = raw
+		case *int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				errs = append(errs, ValidationError{v.field, fmt.Sprintf("%s=%q não é um número inteiro", v.name, raw)})
+				continue
+			}
+			*p = n
+		case *bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				errs = append(errs, ValidationError{v.field, fmt.Sprintf("%s=%q não é um booleano (use true ou false)", v.name, raw)})
+				continue
+			}
+			*p = b
+		}
+	}
+	return errs
+}
@@ -0,0 +1,203 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// Enrichments são os valores aceitos em Profile.Enrichment, na ordem de
+// api.Enrichment.
+var Enrichments = []string{"none", "trailers", "details"}
+
+var (
+	languageCode = regexp.MustCompile(`^[a-z]{2}$`)
+	countryCode  = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// Profile é uma coleta nomeada: filtros de /discover, ordenação, páginas e
+// enriquecimento próprios. Campos vazios herdam das seções tmdb e fetch;
+// Config.Profile devolve o perfil com esses valores preenchidos.
+type Profile struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// MediaType é "movie" ou "tv".
+	MediaType string `json:"media_type" yaml:"media_type" toml:"media_type"`
+	// Language é o idioma das respostas, como tmdb.language.
+	Language string `json:"language" yaml:"language" toml:"language"`
+	// OriginalLanguages filtra pelo idioma original (ex.: ["pt"]).
+	OriginalLanguages []string `json:"original_languages" yaml:"original_languages" toml:"original_languages"`
+	// OriginCountries filtra pelo país de origem (ex.: ["BR"]).
+	OriginCountries []string `json:"origin_countries" yaml:"origin_countries" toml:"origin_countries"`
+	// Region restringe as datas de lançamento de filmes a um país.
+	Region string `json:"region" yaml:"region" toml:"region"`
+	// Genres filtra pelos IDs de gênero do TMDB; todos precisam estar
+	// presentes.
+	Genres         []int   `json:"genres" yaml:"genres" toml:"genres"`
+	MinVoteAverage float64 `json:"min_vote_average" yaml:"min_vote_average" toml:"min_vote_average"`
+	MinVoteCount   int     `json:"min_vote_count" yaml:"min_vote_count" toml:"min_vote_count"`
+	// MinReleaseDate e MaxReleaseDate seguem as regras dos campos de
+	// fetch; ver ResolveDate.
+	MinReleaseDate string     `json:"min_release_date" yaml:"min_release_date" toml:"min_release_date"`
+	MaxReleaseDate string     `json:"max_release_date" yaml:"max_release_date" toml:"max_release_date"`
+	IncludeAdult   *bool      `json:"include_adult,omitempty" yaml:"include_adult,omitempty" toml:"include_adult,omitempty"`
+	IncludeVideo   *bool      `json:"include_video,omitempty" yaml:"include_video,omitempty" toml:"include_video,omitempty"`
+	Sort           SortConfig `json:"sort" yaml:"sort" toml:"sort"`
+	// Pages é o número de páginas lidas, como fetch.num_pages.
+	Pages int `json:"pages" yaml:"pages" toml:"pages"`
+	// Enrichment é "none" (padrão), "trailers" ou "details"; ver
+	// api.Enrichment. Concurrency limita as requisições simultâneas.
+	Enrichment  string `json:"enrichment" yaml:"enrichment" toml:"enrichment"`
+	Concurrency int    `json:"concurrency" yaml:"concurrency" toml:"concurrency"`
+}
+
+// Profile devolve o perfil name com os campos vazios preenchidos pelas
+// seções tmdb e fetch.
+func (c *Config) Profile(name string) (Profile, error) {
+	for _, p := range c.Profiles {
+		if p.Name == name {
+			return c.resolveProfile(p), nil
+		}
+	}
+	return Profile{}, fmt.Errorf("perfil desconhecido: %q", name)
+}
+
+// ProfileNames devolve os nomes dos perfis, na ordem do arquivo.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, len(c.Profiles))
+	for i, p := range c.Profiles {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// FetchProfile devolve o perfil equivalente à seção fetch para o tipo de
+// mídia informado, usado pelas buscas em /discover sem perfil.
+func (c *Config) FetchProfile(mediaType string) Profile {
+	return c.resolveProfile(Profile{MediaType: mediaType})
+}
+
+func (c *Config) resolveProfile(p Profile) Profile {
+	if p.Language == "" {
+		p.Language = c.TMDB.Language
+	}
+	if p.MinReleaseDate == "" {
+		p.MinReleaseDate = c.Fetch.MinReleaseDate
+	}
+	if p.MaxReleaseDate == "" {
+		p.MaxReleaseDate = c.Fetch.MaxReleaseDate
+	}
+	if p.IncludeAdult == nil {
+		includeAdult := c.Fetch.IncludeAdult
+		p.IncludeAdult = &includeAdult
+	}
+	if p.IncludeVideo == nil {
+		includeVideo := c.Fetch.IncludeVideo
+		p.IncludeVideo = &includeVideo
+	}
+	sort := c.Fetch.Sort.Movies
+	if p.MediaType == models.MediaTypeTV {
+		sort = c.Fetch.Sort.TVShows
+	}
+	if p.Sort.Field == "" {
+		p.Sort.Field = sort.Field
+	}
+	if p.Sort.Direction == "" {
+		p.Sort.Direction = sort.Direction
+	}
+	if p.Pages == 0 {
+		p.Pages = c.Fetch.NumPages
+	}
+	if p.Enrichment == "" {
+		p.Enrichment = "none"
+	}
+	return p
+}
+
+// validateProfiles confere os perfis já com os valores herdados, de modo
+// que um campo inválido em fetch não seja repetido em cada perfil.
+func (c *Config) validateProfiles(add func(field, format string, args ...interface{})) {
+	seen := make(map[string]bool, len(c.Profiles))
+	now := time.Now()
+	for i, raw := range c.Profiles {
+		prefix := fmt.Sprintf("profiles[%d]", i)
+		if raw.Name == "" {
+			add(prefix+".name", "obrigatório")
+		} else {
+			prefix = "profiles." + raw.Name
+			if seen[raw.Name] {
+				add(prefix+".name", "perfil repetido")
+			}
+			seen[raw.Name] = true
+		}
+		p := c.resolveProfile(raw)
+
+		allowedSort := MovieSortFields
+		switch p.MediaType {
+		case models.MediaTypeMovie:
+		case models.MediaTypeTV:
+			allowedSort = TVShowSortFields
+			if p.Region != "" {
+				add(prefix+".region", "só é suportado com media_type movie")
+			}
+		default:
+			add(prefix+".media_type", "tipo inválido: %q (use movie ou tv)", p.MediaType)
+		}
+		if raw.Language != "" && !languageTag.MatchString(p.Language) {
+			add(prefix+".language", "idioma inválido: %q (use o formato \"pt\" ou \"pt-BR\")", p.Language)
+		}
+		for _, l := range p.OriginalLanguages {
+			if !languageCode.MatchString(l) {
+				add(prefix+".original_languages", "idioma inválido: %q (use códigos ISO 639-1, como \"pt\")", l)
+			}
+		}
+		for _, country := range p.OriginCountries {
+			if !countryCode.MatchString(country) {
+				add(prefix+".origin_countries", "país inválido: %q (use códigos ISO 3166-1, como \"BR\")", country)
+			}
+		}
+		if p.Region != "" && !countryCode.MatchString(p.Region) {
+			add(prefix+".region", "país inválido: %q (use códigos ISO 3166-1, como \"BR\")", p.Region)
+		}
+		for _, id := range p.Genres {
+			if id <= 0 {
+				add(prefix+".genres", "ID de gênero inválido: %d", id)
+			}
+		}
+		if p.MinVoteAverage < 0 || p.MinVoteAverage > 10 {
+			add(prefix+".min_vote_average", "deve estar entre 0 e 10, recebido %v", p.MinVoteAverage)
+		}
+		if p.MinVoteCount < 0 {
+			add(prefix+".min_vote_count", "não pode ser negativo, recebido %d", p.MinVoteCount)
+		}
+		minDate, minErr := ResolveDate(p.MinReleaseDate, now)
+		if minErr != nil && raw.MinReleaseDate != "" {
+			add(prefix+".min_release_date", "data inválida: %q (use AAAA-MM-DD, today, today+30d, -1y...)", p.MinReleaseDate)
+		}
+		maxDate, maxErr := ResolveDate(p.MaxReleaseDate, now)
+		if maxErr != nil && raw.MaxReleaseDate != "" {
+			add(prefix+".max_release_date", "data inválida: %q (use AAAA-MM-DD, today, today+30d, -1y...)", p.MaxReleaseDate)
+		}
+		ownDates := raw.MinReleaseDate != "" || raw.MaxReleaseDate != ""
+		if ownDates && minDate != "" && maxDate != "" && minDate > maxDate {
+			add(prefix+".min_release_date", "%s é posterior a max_release_date %s", minDate, maxDate)
+		}
+		if raw.Sort.Field != "" && p.MediaType != "" && !contains(allowedSort, p.Sort.Field) {
+			add(prefix+".sort.field", "campo de ordenação inválido: %q (use %s)", p.Sort.Field, strings.Join(allowedSort, ", "))
+		}
+		if raw.Sort.Direction != "" && p.Sort.Direction != "asc" && p.Sort.Direction != "desc" {
+			add(prefix+".sort.direction", "direção inválida: %q (use asc ou desc)", p.Sort.Direction)
+		}
+		if raw.Pages != 0 && (p.Pages < 1 || p.Pages > maxPages) {
+			add(prefix+".pages", "deve estar entre 1 e %d, recebido %d", maxPages, p.Pages)
+		}
+		if !contains(Enrichments, p.Enrichment) {
+			add(prefix+".enrichment", "enriquecimento inválido: %q (use %s)", p.Enrichment, strings.Join(Enrichments, ", "))
+		}
+		if p.Concurrency < 0 {
+			add(prefix+".concurrency", "não pode ser negativo, recebido %d", p.Concurrency)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigProfile(t *testing.T) {
+	cfg := fullConfig()
+	cfg.Profiles = append(cfg.Profiles, Profile{Name: "herda", MediaType: "tv"})
+
+	// Um perfil com todos os campos preenchidos volta igual.
+	got, err := cfg.Profile("lancamentos-br")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	if !reflect.DeepEqual(got, cfg.Profiles[0]) {
+		t.Errorf("Profile = %+v, esperado %+v", got, cfg.Profiles[0])
+	}
+
+	got, err = cfg.Profile("herda")
+	if err != nil {
+		t.Fatalf("Profile: %v", err)
+	}
+	adult, video := cfg.Fetch.IncludeAdult, cfg.Fetch.IncludeVideo
+	want := Profile{
+		Name:           "herda",
+		MediaType:      "tv",
+		Language:       cfg.TMDB.Language,
+		MinReleaseDate: cfg.Fetch.MinReleaseDate,
+		MaxReleaseDate: cfg.Fetch.MaxReleaseDate,
+		IncludeAdult:   &adult,
+		IncludeVideo:   &video,
+		Sort:           cfg.Fetch.Sort.TVShows,
+		Pages:          cfg.Fetch.NumPages,
+		Enrichment:     "none",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Profile = %+v, esperado %+v", got, want)
+	}
+	// O perfil resolvido não aponta para os campos de cfg.
+	*got.IncludeAdult = !*got.IncludeAdult
+	if cfg.Fetch.IncludeAdult != adult {
+		t.Error("alterar o perfil resolvido alterou fetch.include_adult")
+	}
+
+	if got := cfg.FetchProfile("movie"); got.Sort != cfg.Fetch.Sort.Movies || got.Name != "" {
+		t.Errorf("FetchProfile(movie) = %+v", got)
+	}
+	if names := cfg.ProfileNames(); !reflect.DeepEqual(names, []string{"lancamentos-br", "series-coreanas", "herda"}) {
+		t.Errorf("ProfileNames = %v", names)
+	}
+}
+
+func TestConfigProfileUnknown(t *testing.T) {
+	cfg := fullConfig()
+	for _, name := range []string{"desconhecido", "", " lancamentos-br"} {
+		if _, err := cfg.Profile(name); err == nil || !strings.Contains(err.Error(), "perfil desconhecido") {
+			t.Errorf("Profile(%q) = %v, esperado erro de perfil desconhecido", name, err)
+		}
+	}
+}
+
+func TestValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(p *Profile)
+		fields []string
+	}{
+		{"sem nome", func(p *Profile) { p.Name = "" }, []string{"profiles[0].name"}},
+		{"repetido", func(p *Profile) { p.Name = "series-coreanas" }, []string{"profiles.series-coreanas.name"}},
+		{"tipo", func(p *Profile) { p.MediaType = "anime" }, []string{"profiles.lancamentos-br.media_type"}},
+		{"região em séries", func(p *Profile) { p.MediaType, p.Sort.Field = "tv", "name" }, []string{"profiles.lancamentos-br.region"}},
+		{"idioma", func(p *Profile) { p.Language = "pt_BR" }, []string{"profiles.lancamentos-br.language"}},
+		{"idioma original", func(p *Profile) { p.OriginalLanguages = []string{"por"} }, []string{"profiles.lancamentos-br.original_languages"}},
+		{"país de origem", func(p *Profile) { p.OriginCountries = []string{"br"} }, []string{"profiles.lancamentos-br.origin_countries"}},
+		{"região", func(p *Profile) { p.Region = "Brasil" }, []string{"profiles.lancamentos-br.region"}},
+		{"gênero", func(p *Profile) { p.Genres = []int{18, 0} }, []string{"profiles.lancamentos-br.genres"}},
+		{"nota", func(p *Profile) { p.MinVoteAverage = 11 }, []string{"profiles.lancamentos-br.min_vote_average"}},
+		{"votos", func(p *Profile) { p.MinVoteCount = -1 }, []string{"profiles.lancamentos-br.min_vote_count"}},
+		{"data mínima", func(p *Profile) { p.MinReleaseDate = "ontem" }, []string{"profiles.lancamentos-br.min_release_date"}},
+		{"data máxima", func(p *Profile) { p.MaxReleaseDate = "amanhã" }, []string{"profiles.lancamentos-br.max_release_date"}},
+		{"datas invertidas", func(p *Profile) {
+			p.MinReleaseDate, p.MaxReleaseDate = "2024-02-01", "2024-01-01"
+		}, []string{"profiles.lancamentos-br.min_release_date"}},
+		{"ordenação", func(p *Profile) { p.Sort.Field = "name" }, []string{"profiles.lancamentos-br.sort.field"}},
+		{"direção", func(p *Profile) { p.Sort.Direction = "up" }, []string{"profiles.lancamentos-br.sort.direction"}},
+		{"páginas", func(p *Profile) { p.Pages = maxPages + 1 }, []string{"profiles.lancamentos-br.pages"}},
+		{"enriquecimento", func(p *Profile) { p.Enrichment = "tudo" }, []string{"profiles.lancamentos-br.enrichment"}},
+		{"concorrência", func(p *Profile) { p.Concurrency = -1 }, []string{"profiles.lancamentos-br.concurrency"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fullConfig()
+			tt.mutate(&cfg.Profiles[0])
+			got := validationFields(t, cfg.Validate())
+			if !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("campos = %v, esperado %v", got, tt.fields)
+			}
+		})
+	}
+}
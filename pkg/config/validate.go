@@ -0,0 +1,172 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxPages é o limite de páginas de /discover aceito pelo TMDB.
+const maxPages = 500
+
+// DateLayout é o formato das datas enviadas ao TMDB e das datas fixas nos
+// campos de data; ver ResolveDate.
+const DateLayout = "2006-01-02"
+
+// MovieSortFields e TVShowSortFields são os campos de ordenação aceitos por
+// /discover/movie e /discover/tv.
+var (
+	MovieSortFields = []string{"popularity", "revenue", "primary_release_date", "release_date",
+		"vote_average", "vote_count", "title", "original_title"}
+	TVShowSortFields = []string{"popularity", "first_air_date", "vote_average", "vote_count",
+		"name", "original_name"}
+)
+
+// DBDrivers liga os drivers aceitos em database.driver ao dialeto SQL de
+// cada um ("sqlite", "postgres" ou "mysql", como em database.Dialect.Name).
+// Vazio equivale a DefaultDBDriver.
+var DBDrivers = map[string]string{
+	"sqlite3":  "sqlite",
+	"sqlite":   "sqlite",
+	"postgres": "postgres",
+	"pgx":      "postgres",
+	"mysql":    "mysql",
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// languageTag aceita códigos ISO 639-1 com país ISO 3166-1 opcional, como
+// "pt" ou "pt-BR".
+var languageTag = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// ValidationError descreve um problema em um campo da configuração,
+// identificado pelo caminho no arquivo (ex.: "fetch.sort.movies.field").
+type ValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidationErrors reúne todos os problemas encontrados, para que sejam
+// corrigidos de uma vez.
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "configuração inválida: " + strings.Join(msgs, "; ")
+}
+
+// Validate confere os campos da configuração e devolve ValidationErrors
+// com todos os problemas, ou nil.
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (c *Config) validate() ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...interface{}) {
+		errs = append(errs, ValidationError{field, fmt.Sprintf(format, args...)})
+	}
+
+	if c.TMDB.APIKey == "" {
+		add("tmdb.api_key", "obrigatório (defina no arquivo ou em TMDB_API_KEY)")
+	}
+	if u, err := url.Parse(c.TMDB.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		add("tmdb.base_url", "URL inválida: %q", c.TMDB.BaseURL)
+	}
+	if !languageTag.MatchString(c.TMDB.Language) {
+		add("tmdb.language", "idioma inválido: %q (use o formato \"pt\" ou \"pt-BR\")", c.TMDB.Language)
+	}
+	if c.TMDB.ConfigCacheTTL != "" {
+		if ttl, err := time.ParseDuration(c.TMDB.ConfigCacheTTL); err != nil || ttl < 0 {
+			add("tmdb.config_cache_ttl", "duração inválida: %q (ex.: \"24h\")", c.TMDB.ConfigCacheTTL)
+		}
+	}
+	if c.Fetch.NumPages < 1 || c.Fetch.NumPages > maxPages {
+		add("fetch.num_pages", "deve estar entre 1 e %d, recebido %d", maxPages, c.Fetch.NumPages)
+	}
+	now := time.Now()
+	resolveDate := func(field, expr string) string {
+		date, err := ResolveDate(expr, now)
+		if err != nil {
+			add(field, "data inválida: %q (use AAAA-MM-DD, today, today+30d, -1y...)", expr)
+		}
+		return date
+	}
+	minDate := resolveDate("fetch.min_release_date", c.Fetch.MinReleaseDate)
+	maxDate := resolveDate("fetch.max_release_date", c.Fetch.MaxReleaseDate)
+	if minDate != "" && maxDate != "" && minDate > maxDate {
+		add("fetch.min_release_date", "%q (%s) é posterior a fetch.max_release_date %q (%s)",
+			c.Fetch.MinReleaseDate, minDate, c.Fetch.MaxReleaseDate, maxDate)
+	}
+	validateSort := func(field string, sort SortConfig, allowed []string) {
+		if !contains(allowed, sort.Field) {
+			add(field+".field", "campo de ordenação inválido: %q (use %s)", sort.Field, strings.Join(allowed, ", "))
+		}
+		if sort.Direction != "asc" && sort.Direction != "desc" {
+			add(field+".direction", "direção inválida: %q (use asc ou desc)", sort.Direction)
+		}
+	}
+	validateSort("fetch.sort.movies", c.Fetch.Sort.Movies, MovieSortFields)
+	validateSort("fetch.sort.tv_shows", c.Fetch.Sort.TVShows, TVShowSortFields)
+
+	db := c.Database
+	if db.Driver == "" {
+		db.Driver = DefaultDBDriver
+	}
+	dialect, ok := DBDrivers[db.Driver]
+	if !ok {
+		drivers := make([]string, 0, len(DBDrivers))
+		for name := range DBDrivers {
+			drivers = append(drivers, name)
+		}
+		sort.Strings(drivers)
+		add("database.driver", "driver inválido: %q (use %s)", db.Driver, strings.Join(drivers, ", "))
+	}
+	sqlite := dialect == "sqlite"
+	if db.DSN == "" && (!sqlite || db.Filename == "") {
+		add("database.dsn", "obrigatório (ou database.filename, com sqlite3 e sqlite)")
+	}
+	if db.MaxOpenConns < 0 {
+		add("database.max_open_conns", "não pode ser negativo, recebido %d", db.MaxOpenConns)
+	}
+	if db.MaxIdleConns < 0 {
+		add("database.max_idle_conns", "não pode ser negativo, recebido %d", db.MaxIdleConns)
+	}
+	if db.BusyTimeout != "" {
+		if d, err := time.ParseDuration(db.BusyTimeout); err != nil || d < 0 {
+			add("database.busy_timeout", "duração inválida: %q (ex.: \"5s\")", db.BusyTimeout)
+		} else if !sqlite {
+			add("database.busy_timeout", "só é suportado com os drivers sqlite3 e sqlite")
+		}
+	}
+	if db.WAL && !sqlite {
+		add("database.wal", "só é suportado com os drivers sqlite3 e sqlite")
+	}
+	if db.TablePrefix != "" && !identifier.MatchString(db.TablePrefix) {
+		add("database.table_prefix", "prefixo inválido: %q (use letras, números e _)", db.TablePrefix)
+	}
+	c.validateProfiles(add)
+	return errs
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
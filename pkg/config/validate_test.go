@@ -0,0 +1,179 @@
+package config
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestValidateDatabaseDrivers(t *testing.T) {
+	for driver := range DBDrivers {
+		cfg := fullConfig()
+		cfg.Database.Driver = driver
+		cfg.Database.WAL = driver == "sqlite" || driver == "sqlite3"
+		cfg.Database.BusyTimeout = ""
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("driver %q recusado: %v", driver, err)
+		}
+	}
+	// Com o driver sqlite, filename dispensa o dsn, como no sqlite3.
+	cfg := fullConfig()
+	cfg.Database.Driver, cfg.Database.DSN = "sqlite", ""
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("sqlite com filename recusado: %v", err)
+	}
+}
+
+// validationFields devolve os campos citados por err, que deve ser um
+// ValidationErrors.
+func validationFields(t *testing.T, err error) []string {
+	t.Helper()
+	var errs ValidationErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("erro = %v, esperado ValidationErrors", err)
+	}
+	fields := make([]string, len(errs))
+	for i, e := range errs {
+		fields[i] = e.Field
+	}
+	return fields
+}
+
+func TestValidateFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		fields []string
+	}{
+		{"sem api key", func(c *Config) { c.TMDB.APIKey = "" }, []string{"tmdb.api_key"}},
+		{"base url sem esquema", func(c *Config) { c.TMDB.BaseURL = "api.themoviedb.org" }, []string{"tmdb.base_url"}},
+		{"idioma", func(c *Config) { c.TMDB.Language = "portugues" }, []string{"tmdb.language"}},
+		{"ttl inválido", func(c *Config) { c.TMDB.ConfigCacheTTL = "1 dia" }, []string{"tmdb.config_cache_ttl"}},
+		{"ttl negativo", func(c *Config) { c.TMDB.ConfigCacheTTL = "-1h" }, []string{"tmdb.config_cache_ttl"}},
+		{"zero páginas", func(c *Config) { c.Fetch.NumPages = 0 }, []string{"fetch.num_pages"}},
+		{"páginas demais", func(c *Config) { c.Fetch.NumPages = maxPages + 1 }, []string{"fetch.num_pages"}},
+		{"data mínima", func(c *Config) { c.Fetch.MinReleaseDate = "ontem" }, []string{"fetch.min_release_date"}},
+		{"data máxima", func(c *Config) { c.Fetch.MaxReleaseDate = "2024-13-01" }, []string{"fetch.max_release_date"}},
+		{"datas invertidas", func(c *Config) {
+			c.Fetch.MinReleaseDate, c.Fetch.MaxReleaseDate = "2024-02-01", "2024-01-01"
+		}, []string{"fetch.min_release_date"}},
+		{"ordenação de filmes", func(c *Config) { c.Fetch.Sort.Movies.Field = "name" }, []string{"fetch.sort.movies.field"}},
+		{"direção de séries", func(c *Config) { c.Fetch.Sort.TVShows.Direction = "up" }, []string{"fetch.sort.tv_shows.direction"}},
+		{"driver", func(c *Config) {
+			c.Database.Driver, c.Database.WAL, c.Database.BusyTimeout = "oracle", false, ""
+		}, []string{"database.driver"}},
+		{"sem dsn", func(c *Config) {
+			c.Database.Driver, c.Database.DSN, c.Database.WAL, c.Database.BusyTimeout = "postgres", "", false, ""
+		}, []string{"database.dsn"}},
+		{"sqlite sem dsn nem arquivo", func(c *Config) { c.Database.DSN, c.Database.Filename = "", "" }, []string{"database.dsn"}},
+		{"conexões abertas", func(c *Config) { c.Database.MaxOpenConns = -1 }, []string{"database.max_open_conns"}},
+		{"conexões ociosas", func(c *Config) { c.Database.MaxIdleConns = -1 }, []string{"database.max_idle_conns"}},
+		{"busy timeout inválido", func(c *Config) { c.Database.BusyTimeout = "5" }, []string{"database.busy_timeout"}},
+		{"busy timeout fora do sqlite", func(c *Config) {
+			c.Database.Driver, c.Database.WAL = "postgres", false
+		}, []string{"database.busy_timeout"}},
+		{"wal fora do sqlite", func(c *Config) {
+			c.Database.Driver, c.Database.BusyTimeout = "mysql", ""
+		}, []string{"database.wal"}},
+		{"prefixo", func(c *Config) { c.Database.TablePrefix = "1-catalogo" }, []string{"database.table_prefix"}},
+		{"vários problemas", func(c *Config) {
+			c.TMDB.APIKey, c.Fetch.NumPages = "", 0
+		}, []string{"tmdb.api_key", "fetch.num_pages"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fullConfig()
+			tt.mutate(cfg)
+			got := validationFields(t, cfg.Validate())
+			if !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("campos = %v, esperado %v", got, tt.fields)
+			}
+		})
+	}
+}
+
+// envOverrides tem, para cada variável de ApplyEnv, um valor válido
+// diferente do de fullConfig.
+var envOverrides = map[string]string{
+	"TMDB_API_KEY":                 "chave-do-ambiente",
+	"TMDB_BASE_URL":                "http://localhost:8080/3",
+	"TMDB_IMAGE_BASE_URL":          "http://localhost:8080/t/p/w342",
+	"TMDB_LANGUAGE":                "es-ES",
+	"TMDB_CONFIG_CACHE_TTL":        "1h",
+	"TMDB_NUM_PAGES":               "7",
+	"TMDB_INCLUDE_ADULT":           "false",
+	"TMDB_INCLUDE_VIDEO":           "false",
+	"TMDB_MIN_RELEASE_DATE":        "2020-01-01",
+	"TMDB_MAX_RELEASE_DATE":        "2021-12-31",
+	"TMDB_SORT_MOVIES_FIELD":       "revenue",
+	"TMDB_SORT_MOVIES_DIRECTION":   "desc",
+	"TMDB_SORT_TV_SHOWS_FIELD":     "name",
+	"TMDB_SORT_TV_SHOWS_DIRECTION": "asc",
+	"TMDB_DB_DRIVER":               "sqlite",
+	"TMDB_DB_DSN":                  "file:ambiente.db",
+	"TMDB_DB_FILENAME":             "ambiente.db",
+	"TMDB_DB_MAX_OPEN_CONNS":       "8",
+	"TMDB_DB_MAX_IDLE_CONNS":       "3",
+	"TMDB_DB_BUSY_TIMEOUT":         "10s",
+	"TMDB_DB_WAL":                  "false",
+	"TMDB_DB_TABLE_PREFIX":         "ambiente_",
+	"TMDB_DB_AUTO_MIGRATE":         "false",
+}
+
+func TestLoadEnvOverridesFile(t *testing.T) {
+	clearEnv(t)
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := fullConfig().Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	for name, value := range envOverrides {
+		t.Setenv(name, value)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	file := envVars(fullConfig())
+	for i, v := range envVars(cfg) {
+		want, ok := envOverrides[v.name]
+		if !ok {
+			t.Errorf("%s sem valor de teste", v.name)
+			continue
+		}
+		if want == envString(file[i]) {
+			t.Errorf("%s: o valor de teste %q é igual ao do arquivo", v.name, want)
+		}
+		if got := envString(v); got != want {
+			t.Errorf("%s = %q, esperado %q (do ambiente)", v.field, got, want)
+		}
+	}
+}
+
+// envString devolve, como texto, o campo ligado à variável.
+func envString(v envVar) string {
+	switch p := v.value.(type) {
+	case *string:
+		return *p
+	case *int:
+		return strconv.Itoa(*p)
+	case *bool:
+		return strconv.FormatBool(*p)
+	}
+	return ""
+}
+
+func TestLoadEnvInvalid(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("TMDB_API_KEY", "chave")
+	t.Setenv("TMDB_NUM_PAGES", "muitas")
+	t.Setenv("TMDB_DB_WAL", "talvez")
+	_, err := Load("")
+	got := validationFields(t, err)
+	want := []string{"fetch.num_pages", "database.wal"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("campos = %v, esperado %v", got, want)
+	}
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// WithAudit liga o modo de auditoria: cada gravação de filme ou série que
+// altere um campo (título, sinopse, trailer, nota...) registra o valor
+// antigo e o novo em media_changes. Inserções não geram registros.
+func WithAudit() Option {
+	return func(o *options) {
+		o.audit = true
+	}
+}
+
+type syncJobKey struct{}
+
+// WithSyncJob identifica, nas mudanças auditadas, a rotina de
+// sincronização que fez as gravações feitas com o contexto devolvido.
+func WithSyncJob(ctx context.Context, job string) context.Context {
+	return context.WithValue(ctx, syncJobKey{}, job)
+}
+
+func syncJob(ctx context.Context) string {
+	job, _ := ctx.Value(syncJobKey{}).(string)
+	return job
+}
+
+// auditFields são as colunas auditadas de filmes e séries, na ordem de
+// movieAuditValues e tvShowAuditValues. popularity fica de fora: muda a
+// cada sincronização e seu histórico já está em movie_metrics e
+// tvshow_metrics.
+func (t mediaTable) auditFields() []string {
+	return []string{t.titleCol, t.originalCol, "overview", t.dateCol, "poster_path", "backdrop_path",
+		"vote_average", "trailer_url"}
+}
+
+// auditSelect lê os valores atuais das colunas auditadas como texto.
+func (d *Database) auditSelect(t mediaTable) string {
+	columns := ""
+	for i, field := range t.auditFields() {
+		if i > 0 {
+			columns += ", "
+		}
+		if field == "vote_average" {
+			columns += "COALESCE(" + field + ", 0)"
+		} else {
+			columns += "COALESCE(" + field + ", '')"
+		}
+	}
+	return d.dialect.Rebind(`SELECT ` + columns + ` FROM ` + d.table(t.table) + ` WHERE id = ?`)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func movieAuditValues(m *models.Movie) []string {
+	return []string{m.Title, m.OriginalTitle, m.Overview, m.ReleaseDate, m.PosterPath, m.BackdropPath,
+		formatFloat(m.VoteAverage), m.TrailerURL}
+}
+
+func tvShowAuditValues(s *models.TVShow) []string {
+	return []string{s.Name, s.OriginalName, s.Overview, s.FirstAirDate, s.PosterPath, s.BackdropPath,
+		formatFloat(s.VoteAverage), s.TrailerURL}
+}
+
+func (d *Database) changeInsert() string {
+	return d.dialect.Rebind(`INSERT INTO ` + d.table("media_changes") +
+		` (media_type, media_id, field, old_value, new_value, sync_job, changed_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+}
+
+// scanAuditValues lê uma linha de auditSelect. Números chegam como float64
+// em alguns drivers e como texto em outros; ambos viram o mesmo texto de
+// formatFloat.
+func scanAuditValues(row *sql.Row, n int) ([]string, error) {
+	values := make([]interface{}, n)
+	dest := make([]interface{}, n)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	result := make([]string, n)
+	for i, v := range values {
+		switch v := v.(type) {
+		case float64:
+			result[i] = formatFloat(v)
+		case int64:
+			result[i] = strconv.FormatInt(v, 10)
+		case []byte:
+			result[i] = normalizeNumber(string(v))
+		case string:
+			result[i] = normalizeNumber(v)
+		}
+	}
+	return result, nil
+}
+
+// normalizeNumber reescreve com formatFloat textos numéricos, como o
+// DOUBLE do MySQL, e mantém os demais.
+func normalizeNumber(s string) string {
+	if f, err := strconv.ParseFloat(s, 64); err == nil && s != "" {
+		return formatFloat(f)
+	}
+	return s
+}
+
+// recordChanges grava em media_changes os campos que diferem entre old e
+// new.
+func recordChanges(ctx context.Context, stmt *sql.Stmt, mediaType string, id int, fields, old, new []string, at time.Time) error {
+	job := syncJob(ctx)
+	for i, field := range fields {
+		if old[i] == new[i] {
+			continue
+		}
+		if _, err := stmt.ExecContext(ctx, mediaType, id, field, old[i], new[i], job, at); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateTrailer troca o trailer de um item, marca a consulta em
+// trailer_checked_at e, no modo de auditoria, registra a mudança.
+func (tx *Tx) updateTrailer(t mediaTable, id int, trailerURL string) error {
+	table := tx.d.table(t.table)
+	if tx.d.audit {
+		var old string
+		err := tx.tx.QueryRowContext(tx.ctx, tx.d.dialect.Rebind(`SELECT COALESCE(trailer_url, '') FROM `+table+` WHERE id = ?`), id).Scan(&old)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == nil && old != trailerURL {
+			if _, err := tx.tx.ExecContext(tx.ctx, tx.d.changeInsert(), t.mediaType, id, "trailer_url", old, trailerURL,
+				syncJob(tx.ctx), time.Now().UTC()); err != nil {
+				return err
+			}
+		}
+	}
+	// content_hash vem primeiro porque o MySQL avalia o SET da esquerda
+	// para a direita e a comparação precisa do trailer antigo.
+	_, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Rebind(`UPDATE `+table+`
+		SET content_hash = CASE WHEN COALESCE(trailer_url, '') = ? THEN content_hash ELSE NULL END,
+			trailer_url = ?, trailer_checked_at = ?
+		WHERE id = ?`), trailerURL, trailerURL, time.Now().UTC(), id)
+	return err
+}
+
+// ChangesSince devolve as mudanças registradas pelo modo de auditoria a
+// partir de since, da mais antiga para a mais recente.
+func (d *Database) ChangesSince(since time.Time) ([]models.MediaChange, error) {
+	return d.ChangesSinceContext(context.Background(), since)
+}
+
+func (d *Database) ChangesSinceContext(ctx context.Context, since time.Time) ([]models.MediaChange, error) {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(`SELECT id, media_type, media_id, field,
+		COALESCE(old_value, ''), COALESCE(new_value, ''), COALESCE(sync_job, ''), changed_at
+		FROM `+d.table("media_changes")+` WHERE changed_at >= ? ORDER BY changed_at, id`), since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var changes []models.MediaChange
+	for rows.Next() {
+		var c models.MediaChange
+		if err := rows.Scan(&c.ID, &c.MediaType, &c.MediaID, &c.Field, &c.OldValue, &c.NewValue, &c.SyncJob, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
@@ -1,215 +1,451 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"time"
+	"sync/atomic"
+
 	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
 type Database struct {
-	db *sql.DB
+	db      *sql.DB
+	dialect Dialect
+	tables  map[string]string
+	audit   bool
+	// schemaReady guarda o resultado positivo de requireSchema.
+	schemaReady atomic.Bool
+}
+
+// As colunas created_at ficam sempre por último nas listas de filmes e
+// séries, para que as atualizações usem columns[1:len-1] e preservem a data
+// da primeira inserção.
+var (
+	movieColumns = []string{"id", "title", "original_title", "overview", "release_date", "poster_path",
+		"backdrop_path", "vote_average", "trailer_url", "popularity", "content_hash", "status", "deleted_at", "updated_at", "created_at"}
+	tvShowColumns = []string{"id", "name", "original_name", "overview", "first_air_date", "poster_path",
+		"backdrop_path", "vote_average", "trailer_url", "popularity", "content_hash", "status", "deleted_at", "updated_at", "created_at"}
+	videoColumns = []string{"id", "media_type", "media_id", "video_key", "name", "site", "type",
+		"size", "official", "published_at", "iso_639_1", "iso_3166_1"}
+	imageColumns = []string{"tmdb_path", "size", "kind", "local_path", "sha256", "bytes", "downloaded_at"}
+)
+
+type options struct {
+	autoMigrate bool
+	dialect     Dialect
+	tablePrefix string
+	tableNames  map[string]string
+	audit       bool
+	err         error
 }
 
-func NewDatabase(dbPath string) (*Database, error) {
+// Option configura NewDatabase, NewDatabaseFromConn e NewDatabaseFromConfig.
+type Option func(*options)
+
+// WithDialect escolhe o dialeto SQL explicitamente, sem detecção pelo
+// driver.
+func WithDialect(dialect Dialect) Option {
+	return func(o *options) {
+		o.dialect = dialect
+	}
+}
+
+// WithAutoMigrate faz NewDatabase aplicar as migrações pendentes ao abrir o
+// banco.
+func WithAutoMigrate() Option {
+	return func(o *options) {
+		o.autoMigrate = true
+	}
+}
+
+func NewDatabase(dbPath string, opts ...Option) (*Database, error) {
 	db, err := sql.Open("sqlite3", dbPath)
 	if err != nil {
 		return nil, err
 	}
+	return openDatabase(db, SQLite, opts)
+}
 
-	if err := db.Ping(); err != nil {
+// openDatabase aplica opts a uma conexão recém-aberta, que é fechada em
+// caso de erro. Ver newDatabase.
+func openDatabase(db *sql.DB, dialect Dialect, opts []Option) (*Database, error) {
+	d, err := newDatabase(db, dialect, opts)
+	if err != nil {
+		db.Close()
 		return nil, err
 	}
+	return d, nil
+}
 
-	return &Database{db: db}, nil
+// newDatabase aplica opts à conexão. O dialeto de WithDialect tem
+// precedência sobre dialect; sem nenhum dos dois, ele é detectado pelo
+// driver.
+func newDatabase(db *sql.DB, dialect Dialect, opts []Option) (*Database, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tables, err := resolveTables(o)
+	if o.err != nil {
+		err = o.err
+	}
+	if o.dialect != nil {
+		dialect = o.dialect
+	}
+	if err == nil && dialect == nil {
+		dialect, err = DetectDialect(db)
+	}
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Database{db: db, dialect: dialect, tables: tables, audit: o.audit}
+	if o.autoMigrate {
+		if err := d.Migrate(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
 }
 
+func (d *Database) Close() error {
+	return d.db.Close()
+}
+
+func (d *Database) Dialect() Dialect {
+	return d.dialect
+}
+
+// SaveMovie grava um filme e, se GenreIDs não for nil, substitui suas
+// relações em movie_genres na mesma transação.
 func (d *Database) SaveMovie(movie *models.Movie) error {
-	query := `INSERT OR REPLACE INTO movies 
-        (id, title, overview, release_date, poster_path, backdrop_path, vote_average, trailer_url, popularity, created_at) 
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	movie.CreatedAt = time.Now()
-	_, err := d.db.Exec(query, movie.ID, movie.Title, movie.Overview,
-		movie.ReleaseDate, movie.PosterPath, movie.BackdropPath, movie.VoteAverage, movie.TrailerURL,
-		movie.Popularity, movie.CreatedAt)
-	return err
+	return d.SaveMovieContext(context.Background(), movie)
 }
 
+func (d *Database) SaveMovieContext(ctx context.Context, movie *models.Movie) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveMovie(movie) })
+}
+
+// SaveTVShow grava uma série e, se GenreIDs não for nil, substitui suas
+// relações em tvshow_genres na mesma transação.
 func (d *Database) SaveTVShow(show *models.TVShow) error {
-	query := `INSERT OR REPLACE INTO tv_shows 
-        (id, name, overview, first_air_date, poster_path, backdrop_path, vote_average, trailer_url, popularity, created_at) 
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	show.CreatedAt = time.Now()
-	_, err := d.db.Exec(query, show.ID, show.Name, show.Overview,
-		show.FirstAirDate, show.PosterPath, show.BackdropPath, show.VoteAverage, show.TrailerURL,
-		show.Popularity, show.CreatedAt)
-	return err
+	return d.SaveTVShowContext(context.Background(), show)
+}
+
+func (d *Database) SaveTVShowContext(ctx context.Context, show *models.TVShow) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveTVShow(show) })
 }
 
+// SaveMoviesBulk grava os filmes e suas relações de gênero em uma única
+// transação. Para cada filme com GenreIDs diferente de nil, as relações
+// existentes são substituídas pelas informadas, removendo gêneros que o
+// TMDB deixou de associar. GenreIDs nil mantém as relações atuais.
+//
+// Cada filme guarda um hash do conteúdo; filmes com o mesmo hash e as
+// mesmas relações não são reescritos. SaveMoviesBulkWithResult também
+// conta os filmes inseridos, atualizados e inalterados.
 func (d *Database) SaveMoviesBulk(movies []models.Movie) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO movies 
-		(id, title, overview, release_date, poster_path, backdrop_path, vote_average, trailer_url, popularity, created_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
-		tx.Rollback()
+	return d.SaveMoviesBulkContext(context.Background(), movies)
+}
+
+func (d *Database) SaveMoviesBulkContext(ctx context.Context, movies []models.Movie) error {
+	_, err := d.SaveMoviesBulkWithResultContext(ctx, movies)
+	return err
+}
+
+// SaveMoviesBulkWithResult é SaveMoviesBulk com a contagem dos filmes
+// inseridos, atualizados e inalterados.
+func (d *Database) SaveMoviesBulkWithResult(movies []models.Movie) (SaveResult, error) {
+	return d.SaveMoviesBulkWithResultContext(context.Background(), movies)
+}
+
+func (d *Database) SaveMoviesBulkWithResultContext(ctx context.Context, movies []models.Movie) (SaveResult, error) {
+	var result SaveResult
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		result, err = tx.SaveMoviesBulkWithResult(movies)
 		return err
-	}
-	defer stmt.Close()
-
-	now := time.Now()
-	for _, movie := range movies {
-		movie.CreatedAt = now
-		_, err := stmt.Exec(movie.ID, movie.Title, movie.Overview,
-			movie.ReleaseDate, movie.PosterPath, movie.BackdropPath, movie.VoteAverage, movie.TrailerURL,
-			movie.Popularity, movie.CreatedAt)
-		if err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-	return tx.Commit()
+	})
+	return result, err
 }
 
+// SaveTVShowsBulk grava as séries e suas relações de gênero em uma única
+// transação, com as mesmas regras de SaveMoviesBulk.
 func (d *Database) SaveTVShowsBulk(shows []models.TVShow) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO tv_shows 
-		(id, name, overview, first_air_date, poster_path, backdrop_path, vote_average, trailer_url, popularity, created_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
-	if err != nil {
-		tx.Rollback()
+	return d.SaveTVShowsBulkContext(context.Background(), shows)
+}
+
+func (d *Database) SaveTVShowsBulkContext(ctx context.Context, shows []models.TVShow) error {
+	_, err := d.SaveTVShowsBulkWithResultContext(ctx, shows)
+	return err
+}
+
+// SaveTVShowsBulkWithResult é SaveTVShowsBulk com a contagem das séries
+// inseridas, atualizadas e inalteradas.
+func (d *Database) SaveTVShowsBulkWithResult(shows []models.TVShow) (SaveResult, error) {
+	return d.SaveTVShowsBulkWithResultContext(context.Background(), shows)
+}
+
+func (d *Database) SaveTVShowsBulkWithResultContext(ctx context.Context, shows []models.TVShow) (SaveResult, error) {
+	var result SaveResult
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		result, err = tx.SaveTVShowsBulkWithResult(shows)
 		return err
-	}
-	defer stmt.Close()
-
-	now := time.Now()
-	for _, show := range shows {
-		show.CreatedAt = now
-		_, err := stmt.Exec(show.ID, show.Name, show.Overview,
-			show.FirstAirDate, show.PosterPath, show.BackdropPath, show.VoteAverage, show.TrailerURL,
-			show.Popularity, show.CreatedAt)
-		if err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-	return tx.Commit()
+	})
+	return result, err
 }
 
+// SaveGenres grava os gêneros separados por MediaType e Language, de modo
+// que as listas de filmes e séries (e de idiomas diferentes) não se
+// sobrescrevam.
+//
+// Gêneros sem MediaType são gravados como models.MediaTypeMovie, para não
+// quebrar quem monta models.Genre{ID, Name} à mão. Esse padrão está
+// obsoleto e pode deixar de existir: preencha MediaType, como já fazem
+// FetchMovieGenres e FetchTVShowGenres.
 func (d *Database) SaveGenres(genres []models.Genre) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO genres (id, name) VALUES (?, ?)`)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	defer stmt.Close()
-	for _, genre := range genres {
-		_, err := stmt.Exec(genre.ID, genre.Name)
-		if err != nil {
-			tx.Rollback()
-			return err
-		}
-	}
-	return tx.Commit()
+	return d.SaveGenresContext(context.Background(), genres)
+}
+
+func (d *Database) SaveGenresContext(ctx context.Context, genres []models.Genre) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveGenres(genres) })
 }
 
 func (d *Database) SaveMovieGenres(movieID int, genreIDs []int) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO movie_genres (movie_id, genre_id) VALUES (?, ?)`)
+	return d.SaveMovieGenresContext(context.Background(), movieID, genreIDs)
+}
+
+func (d *Database) SaveMovieGenresContext(ctx context.Context, movieID int, genreIDs []int) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveMovieGenres(movieID, genreIDs) })
+}
+
+func (d *Database) SaveTVShowGenres(tvShowID int, genreIDs []int) error {
+	return d.SaveTVShowGenresContext(context.Background(), tvShowID, genreIDs)
+}
+
+func (d *Database) SaveTVShowGenresContext(ctx context.Context, tvShowID int, genreIDs []int) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveTVShowGenres(tvShowID, genreIDs) })
+}
+
+func (d *Database) SaveMovieGenresBulk(relations []models.MovieGenre) error {
+	return d.SaveMovieGenresBulkContext(context.Background(), relations)
+}
+
+func (d *Database) SaveMovieGenresBulkContext(ctx context.Context, relations []models.MovieGenre) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveMovieGenresBulk(relations) })
+}
+
+func (d *Database) SaveTVShowGenresBulk(relations []models.TVShowGenre) error {
+	return d.SaveTVShowGenresBulkContext(context.Background(), relations)
+}
+
+func (d *Database) SaveTVShowGenresBulkContext(ctx context.Context, relations []models.TVShowGenre) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveTVShowGenresBulk(relations) })
+}
+
+// SaveVideos substitui a lista de vídeos de um filme ou série.
+func (d *Database) SaveVideos(mediaType string, mediaID int, videos []models.Video) error {
+	return d.SaveVideosContext(context.Background(), mediaType, mediaID, videos)
+}
+
+func (d *Database) SaveVideosContext(ctx context.Context, mediaType string, mediaID int, videos []models.Video) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveVideos(mediaType, mediaID, videos) })
+}
+
+// MovieIDsWithoutTrailer devolve, em ordem crescente, até limit IDs de
+// filmes não removidos com trailer_url vazio e ID maior que afterID. Filmes
+// já consultados por UpdateMovieTrailer ficam de fora, mesmo sem trailer.
+func (d *Database) MovieIDsWithoutTrailer(afterID, limit int) ([]int, error) {
+	return d.MovieIDsWithoutTrailerContext(context.Background(), afterID, limit)
+}
+
+func (d *Database) MovieIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error) {
+	return d.idsWithoutTrailer(ctx, `SELECT id FROM `+d.table("movies")+` 
+		WHERE (trailer_url IS NULL OR trailer_url = '') AND trailer_checked_at IS NULL
+		AND deleted_at IS NULL AND id > ? 
+		ORDER BY id LIMIT ?`, afterID, limit)
+}
+
+// TVShowIDsWithoutTrailer devolve, em ordem crescente, até limit IDs de
+// séries não removidas com trailer_url vazio e ID maior que afterID, com a
+// mesma regra de MovieIDsWithoutTrailer.
+func (d *Database) TVShowIDsWithoutTrailer(afterID, limit int) ([]int, error) {
+	return d.TVShowIDsWithoutTrailerContext(context.Background(), afterID, limit)
+}
+
+func (d *Database) TVShowIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error) {
+	return d.idsWithoutTrailer(ctx, `SELECT id FROM `+d.table("tv_shows")+` 
+		WHERE (trailer_url IS NULL OR trailer_url = '') AND trailer_checked_at IS NULL
+		AND deleted_at IS NULL AND id > ? 
+		ORDER BY id LIMIT ?`, afterID, limit)
+}
+
+func (d *Database) idsWithoutTrailer(ctx context.Context, query string, afterID, limit int) ([]int, error) {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), afterID, limit)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
-	for _, genreID := range genreIDs {
-		_, err := stmt.Exec(movieID, genreID)
-		if err != nil {
-			tx.Rollback()
-			return err
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
 		}
+		ids = append(ids, id)
 	}
-	return tx.Commit()
+	return ids, rows.Err()
 }
 
-func (d *Database) SaveTVShowGenres(tvShowID int, genreIDs []int) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
-	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO tvshow_genres (tvshow_id, genre_id) VALUES (?, ?)`)
+// UpdateMovieTrailer grava o trailer de um filme e o marca como
+// consultado em trailer_checked_at. trailerURL vazio registra que o TMDB
+// não tem trailer, e o filme deixa de aparecer em MovieIDsWithoutTrailer.
+// Quando o trailer muda, o hash do conteúdo é descartado, de modo que o
+// próximo salvamento do filme o reescreva.
+func (d *Database) UpdateMovieTrailer(movieID int, trailerURL string) error {
+	return d.UpdateMovieTrailerContext(context.Background(), movieID, trailerURL)
+}
+
+func (d *Database) UpdateMovieTrailerContext(ctx context.Context, movieID int, trailerURL string) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.UpdateMovieTrailer(movieID, trailerURL) })
+}
+
+// UpdateTVShowTrailer segue a regra de UpdateMovieTrailer.
+func (d *Database) UpdateTVShowTrailer(showID int, trailerURL string) error {
+	return d.UpdateTVShowTrailerContext(context.Background(), showID, trailerURL)
+}
+
+func (d *Database) UpdateTVShowTrailerContext(ctx context.Context, showID int, trailerURL string) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.UpdateTVShowTrailer(showID, trailerURL) })
+}
+
+func (d *Database) SaveCountries(countries []models.Country) error {
+	return d.SaveCountriesContext(context.Background(), countries)
+}
+
+func (d *Database) SaveCountriesContext(ctx context.Context, countries []models.Country) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveCountries(countries) })
+}
+
+func (d *Database) SaveLanguages(languages []models.Language) error {
+	return d.SaveLanguagesContext(context.Background(), languages)
+}
+
+func (d *Database) SaveLanguagesContext(ctx context.Context, languages []models.Language) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveLanguages(languages) })
+}
+
+// ImagePaths devolve os caminhos de pôster e fundo referenciados por
+// filmes e séries, sem repetição. URLs completas gravadas por versões
+// antigas são ignoradas.
+func (d *Database) ImagePaths() ([]models.ImageRef, error) {
+	return d.ImagePathsContext(context.Background())
+}
+
+func (d *Database) ImagePathsContext(ctx context.Context) ([]models.ImageRef, error) {
+	movies, shows := d.table("movies"), d.table("tv_shows")
+	rows, err := d.db.QueryContext(ctx, `
+		SELECT 'poster', poster_path FROM `+movies+` WHERE poster_path LIKE '/%'
+		UNION SELECT 'backdrop', backdrop_path FROM `+movies+` WHERE backdrop_path LIKE '/%'
+		UNION SELECT 'poster', poster_path FROM `+shows+` WHERE poster_path LIKE '/%'
+		UNION SELECT 'backdrop', backdrop_path FROM `+shows+` WHERE backdrop_path LIKE '/%'`)
 	if err != nil {
-		tx.Rollback()
-		return err
+		return nil, err
 	}
-	defer stmt.Close()
-	for _, genreID := range genreIDs {
-		_, err := stmt.Exec(tvShowID, genreID)
-		if err != nil {
-			tx.Rollback()
-			return err
+	defer rows.Close()
+	var refs []models.ImageRef
+	for rows.Next() {
+		var ref models.ImageRef
+		if err := rows.Scan(&ref.Kind, &ref.Path); err != nil {
+			return nil, err
 		}
+		refs = append(refs, ref)
 	}
-	return tx.Commit()
+	return refs, rows.Err()
 }
 
-func (d *Database) SaveMovieGenresBulk(relations []models.MovieGenre) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
+// GetLocalImage devolve a cópia local registrada para o caminho e tamanho,
+// ou nil se a imagem ainda não foi baixada.
+func (d *Database) GetLocalImage(path, size string) (*models.LocalImage, error) {
+	return d.GetLocalImageContext(context.Background(), path, size)
+}
+
+func (d *Database) GetLocalImageContext(ctx context.Context, path, size string) (*models.LocalImage, error) {
+	img := models.LocalImage{Path: path, Size: size}
+	err := d.db.QueryRowContext(ctx, d.dialect.Rebind(`SELECT kind, local_path, sha256, bytes, downloaded_at FROM `+d.table("images")+` 
+		WHERE tmdb_path = ? AND size = ?`), path, size).
+		Scan(&img.Kind, &img.LocalPath, &img.SHA256, &img.Bytes, &img.DownloadedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO movie_genres (movie_id, genre_id) VALUES (?, ?)`)
 	if err != nil {
-		tx.Rollback()
-		return err
-	}
-	defer stmt.Close()
-	for _, rel := range relations {
-		_, err := stmt.Exec(rel.MovieID, rel.GenreID)
-		if err != nil {
-			tx.Rollback()
-			return err
-		}
+		return nil, err
 	}
-	return tx.Commit()
+	return &img, nil
 }
 
-func (d *Database) SaveTVShowGenresBulk(relations []models.TVShowGenre) error {
-	tx, err := d.db.Begin()
-	if err != nil {
-		return err
+func (d *Database) SaveLocalImage(img *models.LocalImage) error {
+	return d.SaveLocalImageContext(context.Background(), img)
+}
+
+func (d *Database) SaveLocalImageContext(ctx context.Context, img *models.LocalImage) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveLocalImage(img) })
+}
+
+func (d *Database) movieUpsert() string {
+	return d.dialect.Upsert(d.table("movies"), movieColumns, []string{"id"}, movieColumns[1:len(movieColumns)-1])
+}
+
+func (d *Database) tvShowUpsert() string {
+	return d.dialect.Upsert(d.table("tv_shows"), tvShowColumns, []string{"id"}, tvShowColumns[1:len(tvShowColumns)-1])
+}
+
+func (d *Database) movieGenreInsert() string {
+	return d.dialect.InsertIgnore(d.table("movie_genres"), []string{"movie_id", "genre_id"}, []string{"movie_id", "genre_id"})
+}
+
+func (d *Database) tvShowGenreInsert() string {
+	return d.dialect.InsertIgnore(d.table("tvshow_genres"), []string{"tvshow_id", "genre_id"}, []string{"tvshow_id", "genre_id"})
+}
+
+// NewDatabaseFromConn usa uma conexão já aberta, com as mesmas opções de
+// NewDatabase: o dialeto é detectado pelo driver (ver DetectDialect), a
+// menos que WithDialect seja informado, e WithAutoMigrate aplica as
+// migrações pendentes. Em caso de erro, a conexão continua aberta e
+// pertence a quem chamou.
+func NewDatabaseFromConn(db *sql.DB, opts ...Option) (*Database, error) {
+	return newDatabase(db, nil, opts)
+}
+
+// NewDatabaseFromDB usa uma conexão já aberta, como NewDatabaseFromConn,
+// mas não aplica migrações mesmo com WithAutoMigrate e nunca falha: opções
+// inválidas são ignoradas (valem os nomes de tabela padrão) e, se o driver
+// não for reconhecido, por exemplo por estar embrulhado por otelsql ou
+// sqlhooks, o dialeto é SQLite.
+//
+// Deprecated: use NewDatabaseFromConn, que devolve esses problemas como
+// erro.
+func NewDatabaseFromDB(db *sql.DB, opts ...Option) *Database {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
 	}
-	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO tvshow_genres (tvshow_id, genre_id) VALUES (?, ?)`)
-	if err != nil {
-		tx.Rollback()
-		return err
+	tables, err := resolveTables(o)
+	if o.err != nil || err != nil {
+		o = options{dialect: o.dialect, audit: o.audit}
+		tables, _ = resolveTables(o)
 	}
-	defer stmt.Close()
-	for _, rel := range relations {
-		_, err := stmt.Exec(rel.TVShowID, rel.GenreID)
-		if err != nil {
-			tx.Rollback()
-			return err
+	dialect := o.dialect
+	if dialect == nil {
+		if dialect, err = DetectDialect(db); err != nil {
+			dialect = SQLite
 		}
 	}
-	return tx.Commit()
-}
-
-func NewDatabaseFromDB(db *sql.DB) *Database {
-	return &Database{db: db}
+	return &Database{db: db, dialect: dialect, tables: tables, audit: o.audit}
 }
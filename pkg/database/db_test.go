@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+func TestNewDatabaseFromConn(t *testing.T) {
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "conn.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := NewDatabaseFromConn(conn, WithTablePrefix("tmdb-")); err == nil {
+		t.Fatal("NewDatabaseFromConn aceitou um prefixo inválido")
+	}
+	// A conexão de quem chamou continua aberta depois do erro.
+	if err := conn.Ping(); err != nil {
+		t.Fatalf("conexão fechada após o erro: %v", err)
+	}
+
+	db, err := NewDatabaseFromConn(conn, WithAutoMigrate())
+	if err != nil {
+		t.Fatalf("NewDatabaseFromConn: %v", err)
+	}
+	if db.Dialect() != SQLite {
+		t.Errorf("dialeto = %v, esperado SQLite", db.Dialect())
+	}
+	if err := db.requireSchema(context.Background()); err != nil {
+		t.Errorf("WithAutoMigrate não aplicou as migrações: %v", err)
+	}
+}
+
+func TestNewDatabaseFromDBWrappedDriver(t *testing.T) {
+	conn, err := sql.Open("wrapped-sqlite3", filepath.Join(t.TempDir(), "wrapped.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := NewDatabaseFromConn(conn); err == nil {
+		t.Error("NewDatabaseFromConn detectou o dialeto de um driver embrulhado")
+	}
+	// O construtor obsoleto não entra em pânico: cai no SQLite e ignora
+	// opções inválidas.
+	db := NewDatabaseFromDB(conn, WithTablePrefix("tmdb-"))
+	if db.Dialect() != SQLite {
+		t.Errorf("dialeto = %v, esperado SQLite", db.Dialect())
+	}
+	if got := db.table("movies"); got != "movies" {
+		t.Errorf("tabela movies = %q, esperado o nome padrão", got)
+	}
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := db.SaveMovie(&models.Movie{ID: 1, Title: "Matrix"}); err != nil {
+		t.Errorf("SaveMovie: %v", err)
+	}
+}
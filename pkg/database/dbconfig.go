@@ -0,0 +1,151 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+)
+
+// NewDatabaseFromConfig abre o banco descrito pela seção database da
+// configuração: driver, DSN (ou arquivo, com SQLite), limites do pool,
+// busy timeout e WAL do SQLite, prefixo das tabelas e migração automática.
+// Com MySQL, parseTime=true é acrescentado ao DSN. opts são aplicadas
+// depois das opções vindas de cfg.
+//
+// Drivers diferentes de sqlite3 precisam ser importados pelo programa, por
+// exemplo com _ "github.com/lib/pq". O dialeto vem do nome do driver ou,
+// para nomes desconhecidos, de DetectDialect; se nenhum dos dois o
+// reconhecer, informe-o com WithDialect.
+func NewDatabaseFromConfig(cfg config.DatabaseConfig, opts ...Option) (*Database, error) {
+	driver := cfg.Driver
+	if driver == "" {
+		driver = config.DefaultDBDriver
+	}
+	dsn := cfg.DSN
+	if dsn == "" {
+		dsn = cfg.Filename
+	}
+	if dsn == "" {
+		return nil, errors.New("configuração do banco sem dsn nem filename")
+	}
+	// Com um nome desconhecido, o dialeto é detectado pela conexão aberta.
+	dialect := driverDialect(driver)
+	var err error
+	switch {
+	case dialect == SQLite:
+		dsn, err = sqliteDSN(driver, dsn, cfg)
+	case cfg.WAL || cfg.BusyTimeout != "":
+		err = fmt.Errorf("busy_timeout e wal só são suportados com os drivers sqlite3 e sqlite")
+	case dialect == MySQL:
+		dsn, err = mysqlDSN(dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir o banco com o driver %q: %v", driver, err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+
+	all := []Option{WithTablePrefix(cfg.TablePrefix)}
+	if cfg.AutoMigrate {
+		all = append(all, WithAutoMigrate())
+	}
+	return openDatabase(db, dialect, append(all, opts...))
+}
+
+// driverDialect devolve o dialeto de um driver de config.DBDrivers, ou nil
+// para nomes desconhecidos.
+func driverDialect(driver string) Dialect {
+	for _, dialect := range []Dialect{SQLite, PostgreSQL, MySQL} {
+		if dialect.Name() == config.DBDrivers[driver] {
+			return dialect
+		}
+	}
+	return nil
+}
+
+// sqliteDSN acrescenta ao DSN os parâmetros do busy timeout e do modo WAL,
+// de modo que valham para todas as conexões do pool. Os nomes dependem do
+// driver: _busy_timeout e _journal_mode no go-sqlite3, _pragma no
+// modernc.org/sqlite.
+func sqliteDSN(driver, dsn string, cfg config.DatabaseConfig) (string, error) {
+	var params []string
+	if cfg.BusyTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.BusyTimeout)
+		if err != nil || timeout < 0 {
+			return "", fmt.Errorf("busy_timeout inválido: %q", cfg.BusyTimeout)
+		}
+		if driver == "sqlite" {
+			params = append(params, fmt.Sprintf("_pragma=busy_timeout(%d)", timeout.Milliseconds()))
+		} else {
+			params = append(params, fmt.Sprintf("_busy_timeout=%d", timeout.Milliseconds()))
+		}
+	}
+	if cfg.WAL {
+		if driver == "sqlite" {
+			params = append(params, "_pragma=journal_mode(WAL)")
+		} else {
+			params = append(params, "_journal_mode=WAL")
+		}
+	}
+	return appendDSNParams(dsn, params), nil
+}
+
+// mysqlDSN garante parseTime=true no DSN do go-sql-driver/mysql: sem ele,
+// as colunas DATETIME chegam como []byte e não podem ser lidas em
+// time.Time ou sql.NullTime.
+func mysqlDSN(dsn string) (string, error) {
+	for _, param := range dsnParams(dsn) {
+		if value, ok := strings.CutPrefix(param, "parseTime="); ok && value != "true" {
+			return "", fmt.Errorf("o DSN do MySQL precisa de parseTime=true, recebido parseTime=%s", value)
+		}
+	}
+	return appendDSNParams(dsn, []string{"parseTime=true"}), nil
+}
+
+// dsnParams devolve os parâmetros (chave=valor) da query string do DSN.
+func dsnParams(dsn string) []string {
+	i := strings.LastIndex(dsn, "?")
+	if i < 0 || i < strings.LastIndex(dsn, "/") {
+		return nil
+	}
+	return strings.Split(dsn[i+1:], "&")
+}
+
+// appendDSNParams acrescenta params à query string do DSN, exceto os que
+// ele já define, que prevalecem. Um _pragma conta como definido quando o
+// DSN já traz o mesmo pragma.
+func appendDSNParams(dsn string, params []string) string {
+	existing := dsnParams(dsn)
+	sep := "?"
+	if existing != nil {
+		sep = "&"
+	}
+	for _, param := range params {
+		key := param[:strings.LastIndexAny(param, "=(")+1]
+		defined := false
+		for _, e := range existing {
+			if strings.HasPrefix(e, key) {
+				defined = true
+				break
+			}
+		}
+		if !defined {
+			dsn += sep + param
+			sep = "&"
+		}
+	}
+	return dsn
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/config"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	both := config.DatabaseConfig{BusyTimeout: "5s", WAL: true}
+	tests := []struct {
+		driver, dsn string
+		cfg         config.DatabaseConfig
+		want        string
+	}{
+		{"sqlite3", "media.db", config.DatabaseConfig{}, "media.db"},
+		{"sqlite3", "media.db", both, "media.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{"sqlite3", "file:media.db", both, "file:media.db?_busy_timeout=5000&_journal_mode=WAL"},
+		{"sqlite3", "file:media.db?cache=shared", both, "file:media.db?cache=shared&_busy_timeout=5000&_journal_mode=WAL"},
+		{"sqlite3", "file:media.db?_busy_timeout=100", both, "file:media.db?_busy_timeout=100&_journal_mode=WAL"},
+		{"sqlite3", "/var/lib/tmdb/media.db", config.DatabaseConfig{WAL: true}, "/var/lib/tmdb/media.db?_journal_mode=WAL"},
+		{"sqlite", "media.db", both, "media.db?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"},
+		{"sqlite", "file:media.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(100)", both,
+			"file:media.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(100)&_pragma=journal_mode(WAL)"},
+	}
+	for _, tt := range tests {
+		got, err := sqliteDSN(tt.driver, tt.dsn, tt.cfg)
+		if err != nil || got != tt.want {
+			t.Errorf("sqliteDSN(%s, %q) = %q, %v; esperado %q", tt.driver, tt.dsn, got, err, tt.want)
+		}
+	}
+	if _, err := sqliteDSN("sqlite3", "media.db", config.DatabaseConfig{BusyTimeout: "5"}); err == nil {
+		t.Error("sqliteDSN aceitou busy_timeout sem unidade")
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	tests := []struct {
+		dsn, want string
+	}{
+		{"user:senha@tcp(localhost:3306)/catalogo", "user:senha@tcp(localhost:3306)/catalogo?parseTime=true"},
+		{"user:senha@tcp(localhost:3306)/catalogo?loc=Local", "user:senha@tcp(localhost:3306)/catalogo?loc=Local&parseTime=true"},
+		{"user:senha@tcp(localhost:3306)/catalogo?parseTime=true", "user:senha@tcp(localhost:3306)/catalogo?parseTime=true"},
+		{"user:s?nha@tcp(localhost:3306)/catalogo", "user:s?nha@tcp(localhost:3306)/catalogo?parseTime=true"},
+	}
+	for _, tt := range tests {
+		got, err := mysqlDSN(tt.dsn)
+		if err != nil || got != tt.want {
+			t.Errorf("mysqlDSN(%q) = %q, %v; esperado %q", tt.dsn, got, err, tt.want)
+		}
+	}
+	if _, err := mysqlDSN("user@/catalogo?parseTime=false"); err == nil {
+		t.Error("mysqlDSN aceitou parseTime=false")
+	}
+}
+
+func TestDriverDialect(t *testing.T) {
+	for driver := range config.DBDrivers {
+		if driverDialect(driver) == nil {
+			t.Errorf("driver %q de config.DBDrivers sem dialeto", driver)
+		}
+	}
+	tests := map[string]Dialect{"sqlite3": SQLite, "sqlite": SQLite, "postgres": PostgreSQL,
+		"pgx": PostgreSQL, "mysql": MySQL, "oracle": nil}
+	for driver, want := range tests {
+		if got := driverDialect(driver); got != want {
+			t.Errorf("driverDialect(%q) = %v, esperado %v", driver, got, want)
+		}
+	}
+}
+
+func TestNewDatabaseFromConfig(t *testing.T) {
+	cfg := config.DatabaseConfig{Filename: filepath.Join(t.TempDir(), "media.db"), WAL: true,
+		BusyTimeout: "1s", AutoMigrate: true, TablePrefix: "tmdb_"}
+	db, err := NewDatabaseFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("NewDatabaseFromConfig: %v", err)
+	}
+	defer db.Close()
+	var mode string
+	if err := db.db.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v; esperado wal", mode, err)
+	}
+	if got := db.table("movies"); got != "tmdb_movies" {
+		t.Errorf("tabela movies = %q", got)
+	}
+
+	if _, err := NewDatabaseFromConfig(config.DatabaseConfig{Driver: "oracle", DSN: "x"}); err == nil {
+		t.Error("NewDatabaseFromConfig aceitou um driver não registrado")
+	}
+	if _, err := NewDatabaseFromConfig(config.DatabaseConfig{Driver: "postgres", DSN: "x", WAL: true}); err == nil {
+		t.Error("NewDatabaseFromConfig aceitou wal com postgres")
+	}
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Dialect isola as diferenças de SQL entre os bancos suportados:
+// placeholders, upserts e o diretório de migrações com a DDL de cada um.
+type Dialect interface {
+	// Name identifica o dialeto e o subdiretório de migrations/.
+	Name() string
+	// Rebind converte os placeholders "?" de uma consulta para o formato
+	// do banco.
+	Rebind(query string) string
+	// Upsert monta um INSERT que, em conflito nas colunas keys, atualiza as
+	// colunas update.
+	Upsert(table string, columns, keys, update []string) string
+	// InsertIgnore monta um INSERT que ignora linhas já existentes.
+	InsertIgnore(table string, columns, keys []string) string
+	// TableExistsQuery devolve uma consulta com um parâmetro (nome da
+	// tabela) que retorna a quantidade de tabelas com esse nome.
+	TableExistsQuery() string
+}
+
+var (
+	SQLite     Dialect = sqliteDialect{}
+	PostgreSQL Dialect = postgresDialect{}
+	MySQL      Dialect = mysqlDialect{}
+)
+
+// driverDialects liga o pacote de cada driver conhecido ao seu dialeto.
+var driverDialects = map[string]Dialect{
+	"github.com/mattn/go-sqlite3":    SQLite,
+	"modernc.org/sqlite":             SQLite,
+	"github.com/lib/pq":              PostgreSQL,
+	"github.com/jackc/pgx/stdlib":    PostgreSQL,
+	"github.com/jackc/pgx/v4/stdlib": PostgreSQL,
+	"github.com/jackc/pgx/v5/stdlib": PostgreSQL,
+	"github.com/go-sql-driver/mysql": MySQL,
+}
+
+// DetectDialect identifica o dialeto pelo pacote do driver da conexão
+// (go-sqlite3, modernc.org/sqlite, lib/pq, pgx e go-sql-driver/mysql).
+// Outros drivers, inclusive os que embrulham um dos conhecidos, devolvem
+// erro: informe o dialeto com WithDialect.
+func DetectDialect(db *sql.DB) (Dialect, error) {
+	t := reflect.TypeOf(db.Driver())
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if dialect, ok := driverDialects[t.PkgPath()]; ok {
+		return dialect, nil
+	}
+	return nil, fmt.Errorf("driver %s não reconhecido; informe o dialeto com WithDialect", reflect.TypeOf(db.Driver()))
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) Rebind(query string) string { return query }
+
+// Upsert usa ON CONFLICT em vez de INSERT OR REPLACE: o REPLACE apaga e
+// reinsere a linha, o que zera colunas fora da lista (como created_at) e
+// dispara ON DELETE CASCADE nas tabelas filhas.
+func (sqliteDialect) Upsert(table string, columns, keys, update []string) string {
+	return onConflictUpsert(table, columns, keys, update)
+}
+
+func (sqliteDialect) InsertIgnore(table string, columns, keys []string) string {
+	return fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)",
+		table, strings.Join(columns, ", "), placeholders(len(columns)))
+}
+
+func (sqliteDialect) TableExistsQuery() string {
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) Rebind(query string) string {
+	var b strings.Builder
+	n := 0
+	inString := false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			inString = !inString
+		case r == '?' && !inString:
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d postgresDialect) Upsert(table string, columns, keys, update []string) string {
+	return d.Rebind(onConflictUpsert(table, columns, keys, update))
+}
+
+func (d postgresDialect) InsertIgnore(table string, columns, keys []string) string {
+	return d.Rebind(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING",
+		table, strings.Join(columns, ", "), placeholders(len(columns)), strings.Join(keys, ", ")))
+}
+
+func (postgresDialect) TableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1`
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string { return "mysql" }
+
+func (mysqlDialect) Rebind(query string) string { return query }
+
+func (mysqlDialect) Upsert(table string, columns, keys, update []string) string {
+	if len(update) == 0 {
+		// Atualizar a chave para ela mesma é a forma de não fazer nada sem
+		// o INSERT IGNORE, que também engoliria outros erros.
+		update = keys[:1]
+	}
+	sets := make([]string, len(update))
+	for i, c := range update {
+		sets[i] = c + " = VALUES(" + c + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s",
+		table, strings.Join(columns, ", "), placeholders(len(columns)), strings.Join(sets, ", "))
+}
+
+// InsertIgnore evita o INSERT IGNORE pelo mesmo motivo de Upsert: ele
+// também transformaria em avisos violações de chave estrangeira e valores
+// truncados.
+func (mysqlDialect) InsertIgnore(table string, columns, keys []string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON DUPLICATE KEY UPDATE %s = %s",
+		table, strings.Join(columns, ", "), placeholders(len(columns)), keys[0], keys[0])
+}
+
+func (mysqlDialect) TableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+}
+
+// onConflictUpsert monta a sintaxe ON CONFLICT comum ao SQLite (3.24+) e ao
+// PostgreSQL.
+func onConflictUpsert(table string, columns, keys, update []string) string {
+	insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s)",
+		table, strings.Join(columns, ", "), placeholders(len(columns)), strings.Join(keys, ", "))
+	if len(update) == 0 {
+		return insert + " DO NOTHING"
+	}
+	sets := make([]string, len(update))
+	for i, c := range update {
+		sets[i] = c + " = excluded." + c
+	}
+	return insert + " DO UPDATE SET " + strings.Join(sets, ", ")
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func (d *Database) tableExists(ctx context.Context, table string) (bool, error) {
+	var n int
+	if err := d.db.QueryRowContext(ctx, d.dialect.TableExistsQuery(), table).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"testing"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestPostgresRebind(t *testing.T) {
+	tests := []struct {
+		query, want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM t WHERE a = ? AND b IN (?, ?)", "SELECT * FROM t WHERE a = $1 AND b IN ($2, $3)"},
+		{"SELECT '?', ? FROM t WHERE a = ?", "SELECT '?', $1 FROM t WHERE a = $2"},
+		{"UPDATE t SET a = 'it''s ?' WHERE b = ?", "UPDATE t SET a = 'it''s ?' WHERE b = $1"},
+	}
+	for _, tt := range tests {
+		if got := PostgreSQL.Rebind(tt.query); got != tt.want {
+			t.Errorf("Rebind(%q) = %q, esperado %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	columns, keys := []string{"id", "a", "b"}, []string{"id"}
+	tests := []struct {
+		dialect Dialect
+		update  []string
+		want    string
+	}{
+		{SQLite, []string{"a", "b"}, "INSERT INTO t (id, a, b) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET a = excluded.a, b = excluded.b"},
+		{SQLite, nil, "INSERT INTO t (id, a, b) VALUES (?, ?, ?) ON CONFLICT (id) DO NOTHING"},
+		{PostgreSQL, []string{"a", "b"}, "INSERT INTO t (id, a, b) VALUES ($1, $2, $3) ON CONFLICT (id) DO UPDATE SET a = excluded.a, b = excluded.b"},
+		{PostgreSQL, nil, "INSERT INTO t (id, a, b) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING"},
+		{MySQL, []string{"a", "b"}, "INSERT INTO t (id, a, b) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE a = VALUES(a), b = VALUES(b)"},
+		{MySQL, nil, "INSERT INTO t (id, a, b) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE id = VALUES(id)"},
+	}
+	for _, tt := range tests {
+		if got := tt.dialect.Upsert("t", columns, keys, tt.update); got != tt.want {
+			t.Errorf("%s Upsert(%v) =\n %q\nesperado\n %q", tt.dialect.Name(), tt.update, got, tt.want)
+		}
+	}
+}
+
+func TestDialectInsertIgnore(t *testing.T) {
+	columns := []string{"movie_id", "genre_id"}
+	tests := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{SQLite, "INSERT OR IGNORE INTO mg (movie_id, genre_id) VALUES (?, ?)"},
+		{PostgreSQL, "INSERT INTO mg (movie_id, genre_id) VALUES ($1, $2) ON CONFLICT (movie_id, genre_id) DO NOTHING"},
+		{MySQL, "INSERT INTO mg (movie_id, genre_id) VALUES (?, ?) ON DUPLICATE KEY UPDATE movie_id = movie_id"},
+	}
+	for _, tt := range tests {
+		if got := tt.dialect.InsertIgnore("mg", columns, columns); got != tt.want {
+			t.Errorf("%s InsertIgnore =\n %q\nesperado\n %q", tt.dialect.Name(), got, tt.want)
+		}
+	}
+}
+
+// wrappedDriver imita drivers que embrulham outro (otelsql, sqlhooks): o
+// pacote do tipo não é o do driver original.
+type wrappedDriver struct{ driver.Driver }
+
+func init() {
+	sql.Register("wrapped-sqlite3", wrappedDriver{&sqlite3.SQLiteDriver{}})
+}
+
+func TestDetectDialect(t *testing.T) {
+	tests := []struct {
+		driver string
+		want   Dialect
+	}{
+		{"sqlite3", SQLite},
+		{"postgres", PostgreSQL},
+		{"mysql", MySQL},
+		{"wrapped-sqlite3", nil},
+	}
+	for _, tt := range tests {
+		// sql.Open não conecta; basta o driver registrado.
+		conn, err := sql.Open(tt.driver, "")
+		if err != nil {
+			t.Fatalf("sql.Open(%q): %v", tt.driver, err)
+		}
+		got, err := DetectDialect(conn)
+		conn.Close()
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("DetectDialect(%s) = %v, esperado erro", tt.driver, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("DetectDialect(%s) = %v, %v; esperado %v", tt.driver, got, err, tt.want)
+		}
+	}
+}
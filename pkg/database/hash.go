@@ -0,0 +1,79 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// SaveResult conta o destino de cada item de um salvamento em lote.
+// Unchanged são os itens cujo conteúdo (e relações de gênero, quando
+// informadas) já estava gravado; eles não são reescritos, e suas datas
+// CreatedAt e UpdatedAt vêm do banco.
+type SaveResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+}
+
+// Add soma os contadores de outro resultado, útil ao salvar em várias
+// chamadas.
+func (r *SaveResult) Add(other SaveResult) {
+	r.Inserted += other.Inserted
+	r.Updated += other.Updated
+	r.Unchanged += other.Unchanged
+}
+
+// contentHash resume os campos gravados de um item. Datas, GenreIDs e
+// Videos ficam de fora: as datas são mantidas pelo banco e as relações são
+// comparadas à parte, já que GenreIDs nil significa "não alterar". A
+// popularidade também fica de fora, porque muda a cada sincronização; ela
+// é gravada junto com o conteúdo e pelos retratos de SaveMovieMetrics e
+// SaveTVShowMetrics.
+func contentHash(fields ...interface{}) string {
+	h := sha256.New()
+	for _, f := range fields {
+		fmt.Fprintf(h, "%v\x00", f)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func movieHash(m *models.Movie) string {
+	return contentHash(m.Title, m.OriginalTitle, m.Overview, m.ReleaseDate, m.PosterPath, m.BackdropPath,
+		m.VoteAverage, m.TrailerURL)
+}
+
+func tvShowHash(s *models.TVShow) string {
+	return contentHash(s.Name, s.OriginalName, s.Overview, s.FirstAirDate, s.PosterPath, s.BackdropPath,
+		s.VoteAverage, s.TrailerURL)
+}
+
+// sameGenreIDs compara as relações gravadas (ordenadas, sem repetição) com
+// as informadas. GenreIDs nil não altera as relações e conta como igual.
+func sameGenreIDs(stored, ids []int) bool {
+	if ids == nil {
+		return true
+	}
+	wanted := append([]int{}, ids...)
+	sort.Ints(wanted)
+	n := 0
+	for i, id := range wanted {
+		if i == 0 || id != wanted[i-1] {
+			wanted[n] = id
+			n++
+		}
+	}
+	wanted = wanted[:n]
+	if len(wanted) != len(stored) {
+		return false
+	}
+	for i := range wanted {
+		if wanted[i] != stored[i] {
+			return false
+		}
+	}
+	return true
+}
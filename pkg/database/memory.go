@@ -0,0 +1,1277 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// MemoryStore implementa Store em memória, com as mesmas regras de
+// Database, para testes de código que depende do catálogo. É seguro para
+// uso concorrente.
+type MemoryStore struct {
+	mu sync.RWMutex
+	// Audit liga o modo de auditoria, como WithAudit em Database: as
+	// mudanças de campos ficam disponíveis em ChangesSinceContext. Deve ser
+	// definido antes do primeiro uso.
+	Audit bool
+	memoryState
+}
+
+// memoryState são os dados de MemoryStore, separados do lock para que
+// InTx trabalhe sobre uma cópia.
+type memoryState struct {
+	movies      map[int]models.Movie
+	shows       map[int]models.TVShow
+	movieGenres map[int][]int
+	showGenres  map[int][]int
+	hashes      map[mediaKey]string
+	metrics     map[mediaKey]map[string]models.MediaMetric
+	genres      map[genreKey]models.Genre
+	videos      map[mediaKey][]models.Video
+	countries   map[string]models.Country
+	languages   map[string]models.Language
+	titles      map[mediaKey][]models.Translation
+	images      map[imageKey]models.LocalImage
+	// trailerChecked marca os itens já consultados por UpdateMovieTrailer
+	// e UpdateTVShowTrailer, como a coluna trailer_checked_at.
+	trailerChecked map[mediaKey]bool
+	// changes são as mudanças registradas com Audit, como media_changes.
+	changes []models.MediaChange
+}
+
+type mediaKey struct {
+	mediaType string
+	id        int
+}
+
+type genreKey struct {
+	id        int
+	mediaType string
+	language  string
+}
+
+type imageKey struct {
+	path string
+	size string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{memoryState: memoryState{
+		movies:      make(map[int]models.Movie),
+		shows:       make(map[int]models.TVShow),
+		movieGenres: make(map[int][]int),
+		showGenres:  make(map[int][]int),
+		hashes:      make(map[mediaKey]string),
+		metrics:     make(map[mediaKey]map[string]models.MediaMetric),
+		genres:      make(map[genreKey]models.Genre),
+		videos:      make(map[mediaKey][]models.Video),
+		countries:   make(map[string]models.Country),
+		languages:   make(map[string]models.Language),
+		titles:      make(map[mediaKey][]models.Translation),
+		images:      make(map[imageKey]models.LocalImage),
+
+		trailerChecked: make(map[mediaKey]bool),
+	}}
+}
+
+// clone copia os mapas do estado. Os valores guardados não são alterados
+// no lugar (as gravações substituem slices e ponteiros), exceto os mapas
+// de métricas, que também são copiados.
+func (st memoryState) clone() memoryState {
+	c := st
+	c.movies = maps.Clone(st.movies)
+	c.shows = maps.Clone(st.shows)
+	c.movieGenres = maps.Clone(st.movieGenres)
+	c.showGenres = maps.Clone(st.showGenres)
+	c.hashes = maps.Clone(st.hashes)
+	c.metrics = make(map[mediaKey]map[string]models.MediaMetric, len(st.metrics))
+	for key, byDate := range st.metrics {
+		c.metrics[key] = maps.Clone(byDate)
+	}
+	c.genres = maps.Clone(st.genres)
+	c.videos = maps.Clone(st.videos)
+	c.countries = maps.Clone(st.countries)
+	c.languages = maps.Clone(st.languages)
+	c.titles = maps.Clone(st.titles)
+	c.images = maps.Clone(st.images)
+	c.trailerChecked = maps.Clone(st.trailerChecked)
+	c.changes = slices.Clone(st.changes)
+	return c
+}
+
+// InTx executa fn sobre uma cópia dos dados e só a aplica se fn não
+// devolver erro, como uma transação de Database. O store fica bloqueado
+// até fn terminar, portanto fn deve usar apenas tx.
+func (s *MemoryStore) InTx(ctx context.Context, fn func(tx TxStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tx := &MemoryStore{Audit: s.Audit, memoryState: s.memoryState.clone()}
+	if err := fn(&memoryTx{ctx: ctx, s: tx}); err != nil {
+		return err
+	}
+	s.memoryState = tx.memoryState
+	return nil
+}
+
+// write e read respeitam o cancelamento do contexto antes de tocar nos
+// dados, como as consultas de Database.
+func (s *MemoryStore) write(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn()
+}
+
+func (s *MemoryStore) read(ctx context.Context, fn func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn()
+}
+
+func (s *MemoryStore) SaveMovieContext(ctx context.Context, movie *models.Movie) error {
+	movies := []models.Movie{*movie}
+	if err := s.SaveMoviesBulkContext(ctx, movies); err != nil {
+		return err
+	}
+	movie.CreatedAt = movies[0].CreatedAt
+	movie.UpdatedAt = movies[0].UpdatedAt
+	return nil
+}
+
+func (s *MemoryStore) SaveTVShowContext(ctx context.Context, show *models.TVShow) error {
+	shows := []models.TVShow{*show}
+	if err := s.SaveTVShowsBulkContext(ctx, shows); err != nil {
+		return err
+	}
+	show.CreatedAt = shows[0].CreatedAt
+	show.UpdatedAt = shows[0].UpdatedAt
+	return nil
+}
+
+func (s *MemoryStore) SaveMoviesBulkContext(ctx context.Context, movies []models.Movie) error {
+	_, err := s.SaveMoviesBulkWithResultContext(ctx, movies)
+	return err
+}
+
+func (s *MemoryStore) SaveMoviesBulkWithResultContext(ctx context.Context, movies []models.Movie) (SaveResult, error) {
+	var result SaveResult
+	err := s.write(ctx, func() error {
+		now := time.Now()
+		for i := range movies {
+			movie := &movies[i]
+			key := mediaKey{models.MediaTypeMovie, movie.ID}
+			existing, exists := s.movies[movie.ID]
+			hash := movieHash(movie)
+			if exists && s.hashes[key] == hash && existing.DeletedAt == nil {
+				movie.CreatedAt = existing.CreatedAt
+				movie.UpdatedAt = existing.UpdatedAt
+				if sameGenreIDs(s.movieGenres[movie.ID], movie.GenreIDs) {
+					result.Unchanged++
+					continue
+				}
+				existing.UpdatedAt = now
+				movie.UpdatedAt = now
+				s.movies[movie.ID] = existing
+				s.movieGenres[movie.ID] = addGenreIDs(nil, movie.GenreIDs)
+				result.Updated++
+				continue
+			}
+
+			movie.CreatedAt = now
+			movie.UpdatedAt = now
+			if exists {
+				movie.CreatedAt = existing.CreatedAt
+			}
+			stored := *movie
+			if exists {
+				s.recordChanges(ctx, models.MediaTypeMovie, movie.ID, moviesTable.auditFields(), movieAuditValues(&existing), movieAuditValues(movie), now.UTC())
+				result.Updated++
+			} else {
+				result.Inserted++
+			}
+			stored.Status = models.StatusActive
+			stored.DeletedAt = nil
+			stored.GenreIDs = nil
+			stored.Videos = nil
+			s.movies[stored.ID] = stored
+			s.hashes[key] = hash
+			if movie.GenreIDs != nil {
+				s.movieGenres[stored.ID] = addGenreIDs(nil, movie.GenreIDs)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *MemoryStore) SaveTVShowsBulkContext(ctx context.Context, shows []models.TVShow) error {
+	_, err := s.SaveTVShowsBulkWithResultContext(ctx, shows)
+	return err
+}
+
+func (s *MemoryStore) SaveTVShowsBulkWithResultContext(ctx context.Context, shows []models.TVShow) (SaveResult, error) {
+	var result SaveResult
+	err := s.write(ctx, func() error {
+		now := time.Now()
+		for i := range shows {
+			show := &shows[i]
+			key := mediaKey{models.MediaTypeTV, show.ID}
+			existing, exists := s.shows[show.ID]
+			hash := tvShowHash(show)
+			if exists && s.hashes[key] == hash && existing.DeletedAt == nil {
+				show.CreatedAt = existing.CreatedAt
+				show.UpdatedAt = existing.UpdatedAt
+				if sameGenreIDs(s.showGenres[show.ID], show.GenreIDs) {
+					result.Unchanged++
+					continue
+				}
+				existing.UpdatedAt = now
+				show.UpdatedAt = now
+				s.shows[show.ID] = existing
+				s.showGenres[show.ID] = addGenreIDs(nil, show.GenreIDs)
+				result.Updated++
+				continue
+			}
+
+			show.CreatedAt = now
+			show.UpdatedAt = now
+			if exists {
+				show.CreatedAt = existing.CreatedAt
+			}
+			stored := *show
+			if exists {
+				s.recordChanges(ctx, models.MediaTypeTV, show.ID, tvShowsTable.auditFields(), tvShowAuditValues(&existing), tvShowAuditValues(show), now.UTC())
+				result.Updated++
+			} else {
+				result.Inserted++
+			}
+			stored.Status = models.StatusActive
+			stored.DeletedAt = nil
+			stored.GenreIDs = nil
+			stored.Videos = nil
+			s.shows[stored.ID] = stored
+			s.hashes[key] = hash
+			if show.GenreIDs != nil {
+				s.showGenres[stored.ID] = addGenreIDs(nil, show.GenreIDs)
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// addGenreIDs acrescenta os IDs sem repetição e mantém a lista ordenada,
+// como as leituras de Database devolvem.
+func addGenreIDs(current, ids []int) []int {
+	result := append([]int{}, current...)
+	for _, id := range ids {
+		if indexOfInt(result, id) < 0 {
+			result = append(result, id)
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+func indexOfInt(list []int, v int) int {
+	for i, x := range list {
+		if x == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s *MemoryStore) SaveGenresContext(ctx context.Context, genres []models.Genre) error {
+	return s.write(ctx, func() error {
+		for _, g := range genres {
+			g.MediaType = genreMediaType(g)
+			s.genres[genreKey{g.ID, g.MediaType, g.Language}] = g
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveMovieGenresContext(ctx context.Context, movieID int, genreIDs []int) error {
+	return s.write(ctx, func() error {
+		s.movieGenres[movieID] = addGenreIDs(s.movieGenres[movieID], genreIDs)
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveTVShowGenresContext(ctx context.Context, tvShowID int, genreIDs []int) error {
+	return s.write(ctx, func() error {
+		s.showGenres[tvShowID] = addGenreIDs(s.showGenres[tvShowID], genreIDs)
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveMovieGenresBulkContext(ctx context.Context, relations []models.MovieGenre) error {
+	return s.write(ctx, func() error {
+		for _, rel := range relations {
+			s.movieGenres[rel.MovieID] = addGenreIDs(s.movieGenres[rel.MovieID], []int{rel.GenreID})
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveTVShowGenresBulkContext(ctx context.Context, relations []models.TVShowGenre) error {
+	return s.write(ctx, func() error {
+		for _, rel := range relations {
+			s.showGenres[rel.TVShowID] = addGenreIDs(s.showGenres[rel.TVShowID], []int{rel.GenreID})
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveVideosContext(ctx context.Context, mediaType string, mediaID int, videos []models.Video) error {
+	return s.write(ctx, func() error {
+		stored := make([]models.Video, len(videos))
+		for i, v := range videos {
+			v.MediaType = mediaType
+			v.MediaID = mediaID
+			stored[i] = v
+		}
+		s.videos[mediaKey{mediaType, mediaID}] = stored
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveCountriesContext(ctx context.Context, countries []models.Country) error {
+	return s.write(ctx, func() error {
+		for _, c := range countries {
+			s.countries[c.ISO3166_1] = c
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveLanguagesContext(ctx context.Context, languages []models.Language) error {
+	return s.write(ctx, func() error {
+		for _, l := range languages {
+			s.languages[l.ISO639_1] = l
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveLocalizedTitlesContext(ctx context.Context, mediaType string, mediaID int, translations []models.Translation) error {
+	return s.write(ctx, func() error {
+		// Como a chave (media_type, media_id, language) no SQL, cada idioma
+		// guarda só o último título.
+		var titles []models.Translation
+		byTag := make(map[string]int)
+		for _, tr := range translations {
+			if tr.Title == "" {
+				continue
+			}
+			if i, ok := byTag[tr.Tag()]; ok {
+				titles[i] = tr
+				continue
+			}
+			byTag[tr.Tag()] = len(titles)
+			titles = append(titles, tr)
+		}
+		s.titles[mediaKey{mediaType, mediaID}] = titles
+		return nil
+	})
+}
+
+func (s *MemoryStore) MovieIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error) {
+	var ids []int
+	err := s.read(ctx, func() error {
+		for id, m := range s.movies {
+			checked := s.trailerChecked[mediaKey{models.MediaTypeMovie, id}]
+			if m.TrailerURL == "" && !checked && m.DeletedAt == nil && id > afterID {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	return firstIDs(ids, limit), err
+}
+
+func (s *MemoryStore) TVShowIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error) {
+	var ids []int
+	err := s.read(ctx, func() error {
+		for id, show := range s.shows {
+			checked := s.trailerChecked[mediaKey{models.MediaTypeTV, id}]
+			if show.TrailerURL == "" && !checked && show.DeletedAt == nil && id > afterID {
+				ids = append(ids, id)
+			}
+		}
+		return nil
+	})
+	return firstIDs(ids, limit), err
+}
+
+func firstIDs(ids []int, limit int) []int {
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids
+}
+
+func (s *MemoryStore) UpdateMovieTrailerContext(ctx context.Context, movieID int, trailerURL string) error {
+	return s.write(ctx, func() error {
+		if m, ok := s.movies[movieID]; ok {
+			key := mediaKey{models.MediaTypeMovie, movieID}
+			if m.TrailerURL != trailerURL {
+				s.recordChanges(ctx, key.mediaType, key.id, []string{"trailer_url"}, []string{m.TrailerURL}, []string{trailerURL}, time.Now().UTC())
+				m.TrailerURL = trailerURL
+				s.movies[movieID] = m
+				delete(s.hashes, key)
+			}
+			s.trailerChecked[key] = true
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) UpdateTVShowTrailerContext(ctx context.Context, showID int, trailerURL string) error {
+	return s.write(ctx, func() error {
+		if show, ok := s.shows[showID]; ok {
+			key := mediaKey{models.MediaTypeTV, showID}
+			if show.TrailerURL != trailerURL {
+				s.recordChanges(ctx, key.mediaType, key.id, []string{"trailer_url"}, []string{show.TrailerURL}, []string{trailerURL}, time.Now().UTC())
+				show.TrailerURL = trailerURL
+				s.shows[showID] = show
+				delete(s.hashes, key)
+			}
+			s.trailerChecked[key] = true
+		}
+		return nil
+	})
+}
+
+// recordChanges registra, com Audit, os campos que diferem entre old e
+// new, como a função de mesmo nome faz em media_changes. Chamado com o
+// lock de escrita.
+func (s *MemoryStore) recordChanges(ctx context.Context, mediaType string, id int, fields, old, new []string, at time.Time) {
+	if !s.Audit {
+		return
+	}
+	for i, field := range fields {
+		if old[i] == new[i] {
+			continue
+		}
+		s.changes = append(s.changes, models.MediaChange{ID: int64(len(s.changes) + 1), MediaType: mediaType,
+			MediaID: id, Field: field, OldValue: old[i], NewValue: new[i], SyncJob: syncJob(ctx), ChangedAt: at})
+	}
+}
+
+// ChangesSinceContext segue as regras de Database.ChangesSinceContext.
+func (s *MemoryStore) ChangesSinceContext(ctx context.Context, since time.Time) ([]models.MediaChange, error) {
+	var changes []models.MediaChange
+	err := s.read(ctx, func() error {
+		for _, c := range s.changes {
+			if !c.ChangedAt.Before(since) {
+				changes = append(changes, c)
+			}
+		}
+		return nil
+	})
+	return changes, err
+}
+
+func (s *MemoryStore) ImagePathsContext(ctx context.Context) ([]models.ImageRef, error) {
+	seen := make(map[models.ImageRef]bool)
+	var refs []models.ImageRef
+	add := func(kind, path string) {
+		ref := models.ImageRef{Kind: kind, Path: path}
+		if strings.HasPrefix(path, "/") && !seen[ref] {
+			seen[ref] = true
+			refs = append(refs, ref)
+		}
+	}
+	err := s.read(ctx, func() error {
+		for _, m := range s.movies {
+			add(models.ImageKindPoster, m.PosterPath)
+			add(models.ImageKindBackdrop, m.BackdropPath)
+		}
+		for _, show := range s.shows {
+			add(models.ImageKindPoster, show.PosterPath)
+			add(models.ImageKindBackdrop, show.BackdropPath)
+		}
+		return nil
+	})
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Kind != refs[j].Kind {
+			return refs[i].Kind < refs[j].Kind
+		}
+		return refs[i].Path < refs[j].Path
+	})
+	return refs, err
+}
+
+func (s *MemoryStore) GetLocalImageContext(ctx context.Context, path, size string) (*models.LocalImage, error) {
+	var img *models.LocalImage
+	err := s.read(ctx, func() error {
+		if stored, ok := s.images[imageKey{path, size}]; ok {
+			img = &stored
+		}
+		return nil
+	})
+	return img, err
+}
+
+func (s *MemoryStore) SaveLocalImageContext(ctx context.Context, img *models.LocalImage) error {
+	return s.write(ctx, func() error {
+		s.images[imageKey{img.Path, img.Size}] = *img
+		return nil
+	})
+}
+
+// movie e show devolvem cópias com GenreIDs preenchido; chamados com o
+// lock de leitura.
+func (s *MemoryStore) movie(id int) (models.Movie, bool) {
+	m, ok := s.movies[id]
+	if ids := s.movieGenres[id]; len(ids) > 0 {
+		m.GenreIDs = append([]int{}, ids...)
+	}
+	return m, ok
+}
+
+func (s *MemoryStore) show(id int) (models.TVShow, bool) {
+	show, ok := s.shows[id]
+	if ids := s.showGenres[id]; len(ids) > 0 {
+		show.GenreIDs = append([]int{}, ids...)
+	}
+	return show, ok
+}
+
+func (s *MemoryStore) GetMovieContext(ctx context.Context, id int) (*models.Movie, error) {
+	movies, err := s.GetMoviesByIDsContext(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, ErrNotFound
+	}
+	return &movies[0], nil
+}
+
+func (s *MemoryStore) GetMoviesByIDsContext(ctx context.Context, ids []int) ([]models.Movie, error) {
+	var movies []models.Movie
+	err := s.read(ctx, func() error {
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if m, ok := s.movie(id); ok && !seen[id] {
+				seen[id] = true
+				movies = append(movies, m)
+			}
+		}
+		return nil
+	})
+	return movies, err
+}
+
+func (s *MemoryStore) GetTVShowContext(ctx context.Context, id int) (*models.TVShow, error) {
+	shows, err := s.GetTVShowsByIDsContext(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(shows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &shows[0], nil
+}
+
+func (s *MemoryStore) GetTVShowsByIDsContext(ctx context.Context, ids []int) ([]models.TVShow, error) {
+	var shows []models.TVShow
+	err := s.read(ctx, func() error {
+		seen := make(map[int]bool, len(ids))
+		for _, id := range ids {
+			if show, ok := s.show(id); ok && !seen[id] {
+				seen[id] = true
+				shows = append(shows, show)
+			}
+		}
+		return nil
+	})
+	return shows, err
+}
+
+// listItem guarda os campos usados por filtros, ordenação e cursores,
+// comuns a filmes e séries.
+type listItem struct {
+	id         int
+	popularity float64
+	vote       float64
+	date       string
+	title      string
+	createdAt  time.Time
+	updatedAt  time.Time
+	genreIDs   []int
+}
+
+func (it listItem) sortValue(field string) interface{} {
+	switch field {
+	case "vote_average":
+		return it.vote
+	case "release_date":
+		return it.date
+	case "title":
+		return it.title
+	case "id":
+		return it.id
+	case "created_at":
+		return orNullTime(it.createdAt)
+	case "updated_at":
+		return orNullTime(it.updatedAt)
+	}
+	return it.popularity
+}
+
+func compareValues(a, b interface{}) int {
+	switch a := a.(type) {
+	case float64:
+		b, _ := b.(float64)
+		return compareOrdered(a, b)
+	case int:
+		b, _ := b.(int)
+		return compareOrdered(a, b)
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	case time.Time:
+		b, _ := b.(time.Time)
+		return a.Compare(b)
+	}
+	return 0
+}
+
+func compareOrdered[T int | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// listItems aplica ListFilter e devolve os índices de items na ordem da
+// página, com um item a mais quando existe próxima página.
+func listItems(items []listItem, f ListFilter) ([]int, int, error) {
+	if _, err := moviesTable.sortColumn(f.SortField, SQLite); err != nil {
+		return nil, 0, err
+	}
+	desc := true
+	switch strings.ToLower(f.SortDirection) {
+	case "", "desc":
+	case "asc":
+		desc = false
+	default:
+		return nil, 0, fmt.Errorf("direção de ordenação inválida: %q", f.SortDirection)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	var cursorValue interface{}
+	cursorID := 0
+	if f.Cursor != "" {
+		var err error
+		cursorValue, cursorID, err = decodeCursor(f.Cursor, f.SortField)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// before informa se a vem antes de b na ordem pedida.
+	before := func(a, b interface{}, aID, bID int) bool {
+		c := compareValues(a, b)
+		if c == 0 {
+			c = compareOrdered(aID, bID)
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	}
+
+	var selected []int
+	for i, it := range items {
+		if len(f.GenreIDs) > 0 && !anyGenre(it.genreIDs, f.GenreIDs) {
+			continue
+		}
+		if f.YearFrom > 0 && it.date < fmt.Sprintf("%04d-01-01", f.YearFrom) {
+			continue
+		}
+		if f.YearTo > 0 && it.date > fmt.Sprintf("%04d-12-31", f.YearTo) {
+			continue
+		}
+		if f.MinVote > 0 && it.vote < f.MinVote {
+			continue
+		}
+		if f.Cursor != "" && !before(cursorValue, it.sortValue(f.SortField), cursorID, it.id) {
+			continue
+		}
+		selected = append(selected, i)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		a, b := items[selected[i]], items[selected[j]]
+		return before(a.sortValue(f.SortField), b.sortValue(f.SortField), a.id, b.id)
+	})
+	if f.Cursor == "" && f.Offset > 0 {
+		if f.Offset >= len(selected) {
+			selected = nil
+		} else {
+			selected = selected[f.Offset:]
+		}
+	}
+	if len(selected) > limit+1 {
+		selected = selected[:limit+1]
+	}
+	return selected, limit, nil
+}
+
+func anyGenre(have, want []int) bool {
+	for _, id := range want {
+		if indexOfInt(have, id) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) ListMoviesContext(ctx context.Context, filter ListFilter) (*MoviePage, error) {
+	var movies []models.Movie
+	err := s.read(ctx, func() error {
+		for id := range s.movies {
+			m, _ := s.movie(id)
+			if m.DeletedAt == nil || filter.IncludeDeleted {
+				movies = append(movies, m)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]listItem, len(movies))
+	for i, m := range movies {
+		items[i] = listItem{m.ID, m.Popularity, m.VoteAverage, m.ReleaseDate, m.Title, m.CreatedAt, m.UpdatedAt, m.GenreIDs}
+	}
+	selected, limit, err := listItems(items, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &MoviePage{}
+	for _, i := range selected {
+		page.Movies = append(page.Movies, movies[i])
+	}
+	if len(page.Movies) > limit {
+		page.Movies = page.Movies[:limit]
+		page.NextCursor, err = movieCursor(filter.SortField, page.Movies[limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) ListTVShowsContext(ctx context.Context, filter ListFilter) (*TVShowPage, error) {
+	var shows []models.TVShow
+	err := s.read(ctx, func() error {
+		for id := range s.shows {
+			show, _ := s.show(id)
+			if show.DeletedAt == nil || filter.IncludeDeleted {
+				shows = append(shows, show)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]listItem, len(shows))
+	for i, show := range shows {
+		items[i] = listItem{show.ID, show.Popularity, show.VoteAverage, show.FirstAirDate, show.Name, show.CreatedAt, show.UpdatedAt, show.GenreIDs}
+	}
+	selected, limit, err := listItems(items, filter)
+	if err != nil {
+		return nil, err
+	}
+	page := &TVShowPage{}
+	for _, i := range selected {
+		page.Shows = append(page.Shows, shows[i])
+	}
+	if len(page.Shows) > limit {
+		page.Shows = page.Shows[:limit]
+		page.NextCursor, err = tvShowCursor(filter.SortField, page.Shows[limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (s *MemoryStore) ListGenresContext(ctx context.Context) ([]models.Genre, error) {
+	return s.genresWhere(ctx, func(models.Genre) bool { return true })
+}
+
+func (s *MemoryStore) ListGenresForContext(ctx context.Context, mediaType, language string) ([]models.Genre, error) {
+	return s.genresWhere(ctx, func(g models.Genre) bool {
+		return g.MediaType == mediaType && (language == "" || g.Language == language)
+	})
+}
+
+func (s *MemoryStore) GetGenreContext(ctx context.Context, mediaType, language string, id int) (*models.Genre, error) {
+	var genre *models.Genre
+	err := s.read(ctx, func() error {
+		if g, ok := s.genres[genreKey{id, mediaType, language}]; ok {
+			genre = &g
+		}
+		return nil
+	})
+	if err == nil && genre == nil {
+		err = ErrNotFound
+	}
+	return genre, err
+}
+
+// genresWhere devolve os gêneros na ordem de ListGenres: tipo, idioma e
+// nome.
+func (s *MemoryStore) genresWhere(ctx context.Context, keep func(models.Genre) bool) ([]models.Genre, error) {
+	var genres []models.Genre
+	err := s.read(ctx, func() error {
+		for _, g := range s.genres {
+			if keep(g) {
+				genres = append(genres, g)
+			}
+		}
+		return nil
+	})
+	sort.Slice(genres, func(i, j int) bool {
+		a, b := genres[i], genres[j]
+		if a.MediaType != b.MediaType {
+			return a.MediaType < b.MediaType
+		}
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		return a.Name < b.Name
+	})
+	return genres, err
+}
+
+// Search segue as regras de Database.Search, com a mesma pontuação do
+// índice FTS4: títulos valem mais que a sinopse.
+func (s *MemoryStore) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var results []SearchResult
+	match := func(mediaType string, id int, titles, overview string) {
+		for _, tr := range s.titles[mediaKey{mediaType, id}] {
+			titles += " " + tr.Title
+		}
+		words := searchTerms(titles + " " + overview)
+		for _, term := range terms {
+			if !hasPrefixWord(words, term) {
+				return
+			}
+		}
+		results = append(results, SearchResult{MediaType: mediaType, ID: id, Score: titleScore(terms, titles)})
+	}
+	err := s.read(ctx, func() error {
+		if opts.MediaType == "" || opts.MediaType == models.MediaTypeMovie {
+			for _, id := range sortedKeys(s.movies) {
+				m := s.movies[id]
+				if m.DeletedAt != nil {
+					continue
+				}
+				match(models.MediaTypeMovie, id, m.Title+" "+m.OriginalTitle, m.Overview)
+			}
+		}
+		if opts.MediaType == "" || opts.MediaType == models.MediaTypeTV {
+			for _, id := range sortedKeys(s.shows) {
+				show := s.shows[id]
+				if show.DeletedAt != nil {
+					continue
+				}
+				match(models.MediaTypeTV, id, show.Name+" "+show.OriginalName, show.Overview)
+			}
+		}
+		results = rankResults(results, limit, opts.Offset)
+		for i, r := range results {
+			if r.MediaType == models.MediaTypeTV {
+				show, _ := s.show(r.ID)
+				results[i].Show = &show
+			} else {
+				m, _ := s.movie(r.ID)
+				results[i].Movie = &m
+			}
+		}
+		return nil
+	})
+	return results, err
+}
+
+func sortedKeys[V any](m map[int]V) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func (s *MemoryStore) SaveMovieMetricsContext(ctx context.Context, date time.Time, movies []models.Movie) error {
+	return s.write(ctx, func() error {
+		for _, m := range movies {
+			s.saveMetric(models.MediaTypeMovie, models.MediaMetric{ID: m.ID, Date: metricDate(date),
+				Popularity: m.Popularity, VoteAverage: m.VoteAverage, VoteCount: m.VoteCount})
+			if stored, ok := s.movies[m.ID]; ok {
+				stored.Popularity = m.Popularity
+				s.movies[m.ID] = stored
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) SaveTVShowMetricsContext(ctx context.Context, date time.Time, shows []models.TVShow) error {
+	return s.write(ctx, func() error {
+		for _, show := range shows {
+			s.saveMetric(models.MediaTypeTV, models.MediaMetric{ID: show.ID, Date: metricDate(date),
+				Popularity: show.Popularity, VoteAverage: show.VoteAverage, VoteCount: show.VoteCount})
+			if stored, ok := s.shows[show.ID]; ok {
+				stored.Popularity = show.Popularity
+				s.shows[show.ID] = stored
+			}
+		}
+		return nil
+	})
+}
+
+func (s *MemoryStore) saveMetric(mediaType string, m models.MediaMetric) {
+	key := mediaKey{mediaType, m.ID}
+	if s.metrics[key] == nil {
+		s.metrics[key] = make(map[string]models.MediaMetric)
+	}
+	s.metrics[key][m.Date] = m
+}
+
+func (s *MemoryStore) MovieMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return s.metricHistory(ctx, mediaKey{models.MediaTypeMovie, id}, since, until)
+}
+
+func (s *MemoryStore) TVShowMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return s.metricHistory(ctx, mediaKey{models.MediaTypeTV, id}, since, until)
+}
+
+func (s *MemoryStore) metricHistory(ctx context.Context, key mediaKey, since, until time.Time) ([]models.MediaMetric, error) {
+	var metrics []models.MediaMetric
+	err := s.read(ctx, func() error {
+		metrics = metricsBetween(s.metrics[key], metricDate(since), metricDate(until))
+		return nil
+	})
+	return metrics, err
+}
+
+// metricsBetween devolve, em ordem de data, os retratos entre from e to.
+func metricsBetween(byDate map[string]models.MediaMetric, from, to string) []models.MediaMetric {
+	var metrics []models.MediaMetric
+	for date, m := range byDate {
+		if date >= from && date <= to {
+			metrics = append(metrics, m)
+		}
+	}
+	sort.Slice(metrics, func(i, j int) bool { return metrics[i].Date < metrics[j].Date })
+	return metrics
+}
+
+func (s *MemoryStore) MovieMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error) {
+	return s.movers(ctx, models.MediaTypeMovie, opts)
+}
+
+func (s *MemoryStore) TVShowMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error) {
+	return s.movers(ctx, models.MediaTypeTV, opts)
+}
+
+func (s *MemoryStore) movers(ctx context.Context, mediaType string, opts MoversOptions) ([]Mover, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	var movers []Mover
+	err = s.read(ctx, func() error {
+		from, to := metricDate(opts.Since), metricDate(opts.Until)
+		for key, byDate := range s.metrics {
+			if key.mediaType != mediaType {
+				continue
+			}
+			window := metricsBetween(byDate, from, to)
+			if len(window) < 2 {
+				continue
+			}
+			first, last := window[0], window[len(window)-1]
+			movers = append(movers, Mover{ID: key.id, From: first, To: last,
+				Change: metricValue(last, opts.Field) - metricValue(first, opts.Field)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(movers, func(i, j int) bool {
+		if movers[i].Change != movers[j].Change {
+			if opts.Falling {
+				return movers[i].Change < movers[j].Change
+			}
+			return movers[i].Change > movers[j].Change
+		}
+		return movers[i].ID < movers[j].ID
+	})
+	if len(movers) > opts.Limit {
+		movers = movers[:opts.Limit]
+	}
+	return movers, nil
+}
+
+func (s *MemoryStore) SoftDeleteMoviesContext(ctx context.Context, ids []int, status string) (int, error) {
+	return s.softDelete(ctx, models.MediaTypeMovie, ids, status)
+}
+
+func (s *MemoryStore) SoftDeleteTVShowsContext(ctx context.Context, ids []int, status string) (int, error) {
+	return s.softDelete(ctx, models.MediaTypeTV, ids, status)
+}
+
+func (s *MemoryStore) softDelete(ctx context.Context, mediaType string, ids []int, status string) (int, error) {
+	if status == "" || status == models.StatusActive {
+		return 0, fmt.Errorf("status de remoção inválido: %q", status)
+	}
+	n := 0
+	err := s.write(ctx, func() error {
+		n = s.markDeleted(mediaType, ids, status, time.Now().UTC())
+		return nil
+	})
+	return n, err
+}
+
+// markDeleted marca os itens ativos entre ids; chamado com o lock de
+// escrita.
+func (s *MemoryStore) markDeleted(mediaType string, ids []int, status string, at time.Time) int {
+	n := 0
+	for _, id := range ids {
+		if mediaType == models.MediaTypeTV {
+			show, ok := s.shows[id]
+			if !ok || show.DeletedAt != nil {
+				continue
+			}
+			show.Status, show.DeletedAt = status, &at
+			s.shows[id] = show
+		} else {
+			m, ok := s.movies[id]
+			if !ok || m.DeletedAt != nil {
+				continue
+			}
+			m.Status, m.DeletedAt = status, &at
+			s.movies[id] = m
+		}
+		n++
+	}
+	return n
+}
+
+func (s *MemoryStore) MarkMissingMoviesContext(ctx context.Context, seen []int) (int, error) {
+	n := 0
+	err := s.write(ctx, func() error {
+		n = s.markDeleted(models.MediaTypeMovie, missingIDs(s.movies, seen), models.StatusMissing, time.Now().UTC())
+		return nil
+	})
+	return n, err
+}
+
+func (s *MemoryStore) MarkMissingTVShowsContext(ctx context.Context, seen []int) (int, error) {
+	n := 0
+	err := s.write(ctx, func() error {
+		n = s.markDeleted(models.MediaTypeTV, missingIDs(s.shows, seen), models.StatusMissing, time.Now().UTC())
+		return nil
+	})
+	return n, err
+}
+
+// missingIDs devolve as chaves de items que não estão em seen.
+func missingIDs[V any](items map[int]V, seen []int) []int {
+	found := make(map[int]bool, len(seen))
+	for _, id := range seen {
+		found[id] = true
+	}
+	var missing []int
+	for _, id := range sortedKeys(items) {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func (s *MemoryStore) PurgeDeletedContext(ctx context.Context, olderThan time.Duration) (PurgeResult, error) {
+	var result PurgeResult
+	err := s.write(ctx, func() error {
+		cutoff := time.Now().UTC().Add(-olderThan)
+		expired := func(deletedAt *time.Time) bool {
+			return deletedAt != nil && deletedAt.Before(cutoff)
+		}
+		for id, m := range s.movies {
+			if expired(m.DeletedAt) {
+				delete(s.movies, id)
+				delete(s.movieGenres, id)
+				s.purgeMedia(mediaKey{models.MediaTypeMovie, id})
+				result.Movies++
+			}
+		}
+		for id, show := range s.shows {
+			if expired(show.DeletedAt) {
+				delete(s.shows, id)
+				delete(s.showGenres, id)
+				s.purgeMedia(mediaKey{models.MediaTypeTV, id})
+				result.TVShows++
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (s *MemoryStore) purgeMedia(key mediaKey) {
+	delete(s.hashes, key)
+	delete(s.metrics, key)
+	delete(s.videos, key)
+	delete(s.titles, key)
+	delete(s.trailerChecked, key)
+}
+
+// memoryTx é o TxStore de MemoryStore.InTx: cada método chama a variante
+// ...Context da cópia dos dados com o contexto da transação.
+type memoryTx struct {
+	ctx context.Context
+	s   *MemoryStore
+}
+
+func (tx *memoryTx) SaveMovie(movie *models.Movie) error {
+	return tx.s.SaveMovieContext(tx.ctx, movie)
+}
+
+func (tx *memoryTx) SaveTVShow(show *models.TVShow) error {
+	return tx.s.SaveTVShowContext(tx.ctx, show)
+}
+
+func (tx *memoryTx) SaveMoviesBulk(movies []models.Movie) error {
+	return tx.s.SaveMoviesBulkContext(tx.ctx, movies)
+}
+
+func (tx *memoryTx) SaveTVShowsBulk(shows []models.TVShow) error {
+	return tx.s.SaveTVShowsBulkContext(tx.ctx, shows)
+}
+
+func (tx *memoryTx) SaveMoviesBulkWithResult(movies []models.Movie) (SaveResult, error) {
+	return tx.s.SaveMoviesBulkWithResultContext(tx.ctx, movies)
+}
+
+func (tx *memoryTx) SaveTVShowsBulkWithResult(shows []models.TVShow) (SaveResult, error) {
+	return tx.s.SaveTVShowsBulkWithResultContext(tx.ctx, shows)
+}
+
+func (tx *memoryTx) SaveGenres(genres []models.Genre) error {
+	return tx.s.SaveGenresContext(tx.ctx, genres)
+}
+
+func (tx *memoryTx) SaveMovieGenres(movieID int, genreIDs []int) error {
+	return tx.s.SaveMovieGenresContext(tx.ctx, movieID, genreIDs)
+}
+
+func (tx *memoryTx) SaveTVShowGenres(tvShowID int, genreIDs []int) error {
+	return tx.s.SaveTVShowGenresContext(tx.ctx, tvShowID, genreIDs)
+}
+
+func (tx *memoryTx) SaveMovieGenresBulk(relations []models.MovieGenre) error {
+	return tx.s.SaveMovieGenresBulkContext(tx.ctx, relations)
+}
+
+func (tx *memoryTx) SaveTVShowGenresBulk(relations []models.TVShowGenre) error {
+	return tx.s.SaveTVShowGenresBulkContext(tx.ctx, relations)
+}
+
+func (tx *memoryTx) SaveVideos(mediaType string, mediaID int, videos []models.Video) error {
+	return tx.s.SaveVideosContext(tx.ctx, mediaType, mediaID, videos)
+}
+
+func (tx *memoryTx) SaveCountries(countries []models.Country) error {
+	return tx.s.SaveCountriesContext(tx.ctx, countries)
+}
+
+func (tx *memoryTx) SaveLanguages(languages []models.Language) error {
+	return tx.s.SaveLanguagesContext(tx.ctx, languages)
+}
+
+func (tx *memoryTx) SaveLocalizedTitles(mediaType string, mediaID int, translations []models.Translation) error {
+	return tx.s.SaveLocalizedTitlesContext(tx.ctx, mediaType, mediaID, translations)
+}
+
+func (tx *memoryTx) SaveMovieMetrics(date time.Time, movies []models.Movie) error {
+	return tx.s.SaveMovieMetricsContext(tx.ctx, date, movies)
+}
+
+func (tx *memoryTx) SaveTVShowMetrics(date time.Time, shows []models.TVShow) error {
+	return tx.s.SaveTVShowMetricsContext(tx.ctx, date, shows)
+}
+
+func (tx *memoryTx) SaveLocalImage(img *models.LocalImage) error {
+	return tx.s.SaveLocalImageContext(tx.ctx, img)
+}
+
+func (tx *memoryTx) UpdateMovieTrailer(movieID int, trailerURL string) error {
+	return tx.s.UpdateMovieTrailerContext(tx.ctx, movieID, trailerURL)
+}
+
+func (tx *memoryTx) UpdateTVShowTrailer(showID int, trailerURL string) error {
+	return tx.s.UpdateTVShowTrailerContext(tx.ctx, showID, trailerURL)
+}
+
+func (tx *memoryTx) SoftDeleteMovies(ids []int, status string) (int, error) {
+	return tx.s.SoftDeleteMoviesContext(tx.ctx, ids, status)
+}
+
+func (tx *memoryTx) SoftDeleteTVShows(ids []int, status string) (int, error) {
+	return tx.s.SoftDeleteTVShowsContext(tx.ctx, ids, status)
+}
+
+func (tx *memoryTx) MarkMissingMovies(seen []int) (int, error) {
+	return tx.s.MarkMissingMoviesContext(tx.ctx, seen)
+}
+
+func (tx *memoryTx) MarkMissingTVShows(seen []int) (int, error) {
+	return tx.s.MarkMissingTVShowsContext(tx.ctx, seen)
+}
+
+func (tx *memoryTx) PurgeDeleted(olderThan time.Duration) (PurgeResult, error) {
+	return tx.s.PurgeDeletedContext(tx.ctx, olderThan)
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+const (
+	metricDateLayout    = "2006-01-02"
+	defaultMoversWindow = 7 * 24 * time.Hour
+	defaultMoversField  = "popularity"
+)
+
+var metricColumns = []string{"id", "date", "popularity", "vote_average", "vote_count"}
+
+// MoversOptions configura MovieMovers e TVShowMovers.
+type MoversOptions struct {
+	// Field é a métrica comparada: "popularity" (padrão), "vote_average"
+	// ou "vote_count".
+	Field string
+	// Since e Until limitam a janela pelas datas dos retratos. Until zero
+	// usa hoje e Since zero usa sete dias antes de Until.
+	Since time.Time
+	Until time.Time
+	// Falling ordena pelas maiores quedas em vez das maiores altas.
+	Falling bool
+	// Limit é o número máximo de itens. Zero usa 20.
+	Limit int
+}
+
+// Mover é a variação de um filme ou série entre o primeiro e o último
+// retrato da janela.
+type Mover struct {
+	ID     int
+	From   models.MediaMetric
+	To     models.MediaMetric
+	Change float64
+}
+
+func (o MoversOptions) normalize() (MoversOptions, error) {
+	switch o.Field {
+	case "":
+		o.Field = defaultMoversField
+	case "popularity", "vote_average", "vote_count":
+	default:
+		return o, fmt.Errorf("métrica inválida: %q", o.Field)
+	}
+	if o.Until.IsZero() {
+		o.Until = time.Now()
+	}
+	if o.Since.IsZero() {
+		o.Since = o.Until.Add(-defaultMoversWindow)
+	}
+	if o.Limit <= 0 {
+		o.Limit = defaultListLimit
+	}
+	return o, nil
+}
+
+func metricValue(m models.MediaMetric, field string) float64 {
+	switch field {
+	case "vote_average":
+		return m.VoteAverage
+	case "vote_count":
+		return float64(m.VoteCount)
+	}
+	return m.Popularity
+}
+
+// metricDate devolve o dia de t, em UTC, no formato das tabelas de
+// métricas.
+func metricDate(t time.Time) string {
+	return t.UTC().Format(metricDateLayout)
+}
+
+// SaveMovieMetrics grava o retrato do dia date (em UTC) da popularidade,
+// nota e quantidade de votos dos filmes. Um segundo retrato no mesmo dia
+// substitui o anterior. A popularidade atual também é gravada na tabela de
+// filmes, já que os saves não reescrevem um filme quando só ela mudou.
+func (d *Database) SaveMovieMetrics(date time.Time, movies []models.Movie) error {
+	return d.SaveMovieMetricsContext(context.Background(), date, movies)
+}
+
+func (d *Database) SaveMovieMetricsContext(ctx context.Context, date time.Time, movies []models.Movie) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveMovieMetrics(date, movies) })
+}
+
+// SaveTVShowMetrics segue as regras de SaveMovieMetrics.
+func (d *Database) SaveTVShowMetrics(date time.Time, shows []models.TVShow) error {
+	return d.SaveTVShowMetricsContext(context.Background(), date, shows)
+}
+
+func (d *Database) SaveTVShowMetricsContext(ctx context.Context, date time.Time, shows []models.TVShow) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveTVShowMetrics(date, shows) })
+}
+
+func (tx *Tx) SaveMovieMetrics(date time.Time, movies []models.Movie) error {
+	day := metricDate(date)
+	if err := tx.execEach(tx.d.metricUpsert(moviesTable), len(movies), func(i int) []interface{} {
+		m := movies[i]
+		return []interface{}{m.ID, day, m.Popularity, m.VoteAverage, m.VoteCount}
+	}); err != nil {
+		return err
+	}
+	return tx.execEach(tx.d.popularityUpdate(moviesTable), len(movies), func(i int) []interface{} {
+		return []interface{}{movies[i].Popularity, movies[i].ID, movies[i].Popularity}
+	})
+}
+
+func (tx *Tx) SaveTVShowMetrics(date time.Time, shows []models.TVShow) error {
+	day := metricDate(date)
+	if err := tx.execEach(tx.d.metricUpsert(tvShowsTable), len(shows), func(i int) []interface{} {
+		s := shows[i]
+		return []interface{}{s.ID, day, s.Popularity, s.VoteAverage, s.VoteCount}
+	}); err != nil {
+		return err
+	}
+	return tx.execEach(tx.d.popularityUpdate(tvShowsTable), len(shows), func(i int) []interface{} {
+		return []interface{}{shows[i].Popularity, shows[i].ID, shows[i].Popularity}
+	})
+}
+
+// popularityUpdate só altera linhas cuja popularidade mudou, para não
+// reescrever as demais.
+func (d *Database) popularityUpdate(t mediaTable) string {
+	return d.dialect.Rebind(`UPDATE ` + d.table(t.table) + ` SET popularity = ?
+		WHERE id = ? AND (popularity IS NULL OR popularity <> ?)`)
+}
+
+func (d *Database) metricUpsert(t mediaTable) string {
+	return d.dialect.Upsert(d.table(t.metrics), metricColumns, []string{"id", "date"}, metricColumns[2:])
+}
+
+// MovieMetrics devolve os retratos de um filme entre since e until, do mais
+// antigo para o mais recente.
+func (d *Database) MovieMetrics(id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return d.MovieMetricsContext(context.Background(), id, since, until)
+}
+
+func (d *Database) MovieMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return d.metricHistory(ctx, moviesTable, id, since, until)
+}
+
+func (d *Database) TVShowMetrics(id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return d.TVShowMetricsContext(context.Background(), id, since, until)
+}
+
+func (d *Database) TVShowMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error) {
+	return d.metricHistory(ctx, tvShowsTable, id, since, until)
+}
+
+func (d *Database) metricHistory(ctx context.Context, t mediaTable, id int, since, until time.Time) ([]models.MediaMetric, error) {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(`SELECT id, date, COALESCE(popularity, 0), COALESCE(vote_average, 0),
+		COALESCE(vote_count, 0) FROM `+d.table(t.metrics)+` WHERE id = ? AND date >= ? AND date <= ? ORDER BY date`),
+		id, metricDate(since), metricDate(until))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var metrics []models.MediaMetric
+	for rows.Next() {
+		var m models.MediaMetric
+		if err := rows.Scan(&m.ID, &m.Date, &m.Popularity, &m.VoteAverage, &m.VoteCount); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, rows.Err()
+}
+
+// MovieMovers devolve os filmes que mais subiram (ou caíram, com Falling)
+// na métrica escolhida, comparando o primeiro e o último retrato de cada
+// filme na janela. Filmes com um único retrato na janela ficam de fora.
+func (d *Database) MovieMovers(opts MoversOptions) ([]Mover, error) {
+	return d.MovieMoversContext(context.Background(), opts)
+}
+
+func (d *Database) MovieMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error) {
+	return d.movers(ctx, moviesTable, opts)
+}
+
+// TVShowMovers segue as regras de MovieMovers.
+func (d *Database) TVShowMovers(opts MoversOptions) ([]Mover, error) {
+	return d.TVShowMoversContext(context.Background(), opts)
+}
+
+func (d *Database) TVShowMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error) {
+	return d.movers(ctx, tvShowsTable, opts)
+}
+
+func (d *Database) movers(ctx context.Context, t mediaTable, opts MoversOptions) ([]Mover, error) {
+	opts, err := opts.normalize()
+	if err != nil {
+		return nil, err
+	}
+	direction := "DESC"
+	if opts.Falling {
+		direction = "ASC"
+	}
+	table := d.table(t.metrics)
+	metric := func(alias string) string {
+		return fmt.Sprintf("%s.date, COALESCE(%s.popularity, 0), COALESCE(%s.vote_average, 0), COALESCE(%s.vote_count, 0)",
+			alias, alias, alias, alias)
+	}
+	query := fmt.Sprintf(`SELECT w.id, %s, %s
+		FROM (SELECT id, MIN(date) AS first_date, MAX(date) AS last_date FROM %s
+			WHERE date >= ? AND date <= ? GROUP BY id HAVING COUNT(*) > 1) w
+		JOIN %s f ON f.id = w.id AND f.date = w.first_date
+		JOIN %s l ON l.id = w.id AND l.date = w.last_date
+		ORDER BY COALESCE(l.%s, 0) - COALESCE(f.%s, 0) %s, w.id
+		LIMIT ?`, metric("f"), metric("l"), table, table, table, opts.Field, opts.Field, direction)
+
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), metricDate(opts.Since), metricDate(opts.Until), opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var movers []Mover
+	for rows.Next() {
+		var m Mover
+		if err := rows.Scan(&m.ID, &m.From.Date, &m.From.Popularity, &m.From.VoteAverage, &m.From.VoteCount,
+			&m.To.Date, &m.To.Popularity, &m.To.VoteAverage, &m.To.VoteCount); err != nil {
+			return nil, err
+		}
+		m.From.ID, m.To.ID = m.ID, m.ID
+		m.Change = metricValue(m.To, opts.Field) - metricValue(m.From, opts.Field)
+		movers = append(movers, m)
+	}
+	return movers, rows.Err()
+}
@@ -0,0 +1,341 @@
+package database
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration é um passo versionado do esquema. Os arquivos ficam em
+// migrations/<dialeto>/NNNN_nome.sql e são aplicados em ordem crescente de
+// versão.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+func loadMigrations(dialect Dialect) ([]Migration, error) {
+	return readMigrations(migrationFiles, path.Join("migrations", dialect.Name()))
+}
+
+// readMigrations lê os arquivos .sql de dir, em ordem de versão. Duas
+// migrações com a mesma versão são um erro, já que só uma delas seria
+// registrada em schema_migrations.
+func readMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	seen := make(map[int]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || path.Ext(name) != ".sql" {
+			continue
+		}
+		prefix, rest, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), "_")
+		if !ok {
+			return nil, fmt.Errorf("nome de migração inválido: %s", name)
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("nome de migração inválido: %s", name)
+		}
+		if other, ok := seen[version]; ok {
+			return nil, fmt.Errorf("migrações %s e %s com a mesma versão %d", other, name, version)
+		}
+		seen[version] = name
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: rest, SQL: string(content)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrate cria a tabela schema_migrations se necessário e aplica, cada uma
+// em sua transação, as migrações ainda não registradas. Por fim, garante que
+// o índice de busca exista e esteja preenchido.
+func (d *Database) Migrate(ctx context.Context) error {
+	if _, err := d.db.ExecContext(ctx, d.migrationsTableSQL()); err != nil {
+		return fmt.Errorf("erro ao criar schema_migrations: %v", err)
+	}
+
+	current, err := d.VersionContext(ctx)
+	if err != nil {
+		return err
+	}
+	migrations, err := loadMigrations(d.dialect)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := d.applyMigration(ctx, m); err != nil {
+			return fmt.Errorf("erro na migração %04d_%s: %v", m.Version, m.Name, err)
+		}
+	}
+	if err := d.ensureSearchIndex(ctx); err != nil {
+		return err
+	}
+	d.schemaReady.Store(true)
+	return nil
+}
+
+// requireSchema confere, uma vez por Database, se todas as migrações foram
+// aplicadas. As gravações dependem das colunas e do índice de busca criados
+// por elas, e sem essa verificação falhariam com erros de SQL pouco claros.
+func (d *Database) requireSchema(ctx context.Context) error {
+	if d.schemaReady.Load() {
+		return nil
+	}
+	current, err := d.VersionContext(ctx)
+	if err != nil {
+		return err
+	}
+	migrations, err := loadMigrations(d.dialect)
+	if err != nil {
+		return err
+	}
+	if latest := migrations[len(migrations)-1].Version; current < latest {
+		return fmt.Errorf("banco na versão %d, a biblioteca precisa da versão %d: execute Migrate ou use WithAutoMigrate "+
+			"(bancos criados à mão são adotados por Migrate; confira antes com Verify)", current, latest)
+	}
+	d.schemaReady.Store(true)
+	return nil
+}
+
+func (d *Database) migrationsTableSQL() string {
+	timestamp := "DATETIME"
+	if d.dialect == PostgreSQL {
+		timestamp = "TIMESTAMP"
+	}
+	return `CREATE TABLE IF NOT EXISTS ` + d.table("schema_migrations") + ` (
+		version INTEGER PRIMARY KEY,
+		name TEXT NOT NULL,
+		applied_at ` + timestamp + ` NOT NULL
+	)`
+}
+
+func (d *Database) applyMigration(ctx context.Context, m Migration) error {
+	script, err := d.expandTables(m.SQL)
+	if err != nil {
+		return err
+	}
+	if d.dialect == MySQL {
+		return d.applyMigrationSteps(ctx, m, splitStatements(script))
+	}
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range splitStatements(script) {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, d.migrationInsert(), m.Version, m.Name, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (d *Database) migrationInsert() string {
+	return d.dialect.Rebind(`INSERT INTO ` + d.table("schema_migrations") + ` (version, name, applied_at) VALUES (?, ?, ?)`)
+}
+
+// applyMigrationSteps aplica uma migração no MySQL, onde cada DDL confirma
+// a transação implicitamente: uma migração que falhasse no meio ficaria
+// aplicada pela metade, sem registro em schema_migrations. Por isso cada
+// comando aplicado é registrado em schema_migration_steps, e uma nova
+// execução de Migrate retoma a migração a partir do comando seguinte.
+// Comandos que só preparam a sessão (SET @..., PREPARE) não são
+// registrados, para que a retomada os repita junto com o comando que
+// depende deles. Se o processo cair entre um comando e o seu registro, o
+// comando é repetido e pode falhar; nesse caso o banco precisa ser
+// conferido à mão.
+func (d *Database) applyMigrationSteps(ctx context.Context, m Migration, stmts []string) error {
+	// Variáveis de sessão e comandos preparados exigem uma única conexão.
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	steps := d.table("schema_migration_steps")
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS `+steps+` (
+		version INT NOT NULL,
+		step INT NOT NULL,
+		PRIMARY KEY (version, step)
+	)`); err != nil {
+		return fmt.Errorf("erro ao criar schema_migration_steps: %v", err)
+	}
+	var done int
+	if err := conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(step), 0) FROM `+steps+` WHERE version = ?`,
+		m.Version).Scan(&done); err != nil {
+		return err
+	}
+	for i, stmt := range stmts {
+		step := i + 1
+		if step <= done {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("comando %d: %v", step, err)
+		}
+		if sessionOnly(stmt) {
+			continue
+		}
+		if _, err := conn.ExecContext(ctx, `INSERT INTO `+steps+` (version, step) VALUES (?, ?)`, m.Version, step); err != nil {
+			return err
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, d.migrationInsert(), m.Version, m.Name, time.Now()); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+steps+` WHERE version = ?`, m.Version); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// sessionOnly indica um comando que só prepara o estado da sessão.
+func sessionOnly(stmt string) bool {
+	upper := strings.ToUpper(stripComments(stmt))
+	return strings.HasPrefix(upper, "SET @") || strings.HasPrefix(upper, "PREPARE ")
+}
+
+// stripComments remove as linhas de comentário do início de um comando.
+func stripComments(stmt string) string {
+	for strings.HasPrefix(stmt, "--") {
+		_, rest, _ := strings.Cut(stmt, "\n")
+		stmt = strings.TrimSpace(rest)
+	}
+	return stmt
+}
+
+// Version devolve a versão mais recente aplicada, ou 0 se nenhuma migração
+// foi aplicada.
+func (d *Database) Version() (int, error) {
+	return d.VersionContext(context.Background())
+}
+
+func (d *Database) VersionContext(ctx context.Context) (int, error) {
+	exists, err := d.tableExists(ctx, d.table("schema_migrations"))
+	if err != nil || !exists {
+		return 0, err
+	}
+	var version int
+	err = d.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM `+d.table("schema_migrations")).Scan(&version)
+	return version, err
+}
+
+// splitStatements separa o conteúdo de um arquivo de migração em comandos,
+// já que nem todo driver aceita vários comandos em um único Exec. Um
+// comando termina em ";" no fim da linha, fora de strings, identificadores
+// entre aspas, strings com $$ ou $tag$ do PostgreSQL e comentários "--".
+// Em CREATE TRIGGER, PROCEDURE ou FUNCTION com corpo BEGIN ... END, o
+// comando só termina no ";" depois do END; ver migrations/README.md.
+func splitStatements(sql string) []string {
+	var stmts []string
+	var quote rune
+	var dollar string
+	start := 0
+	runes := []rune(sql)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		switch {
+		case dollar != "":
+			if strings.HasPrefix(string(runes[i:]), dollar) {
+				i += len([]rune(dollar)) - 1
+				dollar = ""
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '$':
+			rest := string(runes[i:])
+			if loc := dollarQuote.FindStringIndex(rest); loc != nil && loc[0] == 0 {
+				dollar = rest[:loc[1]]
+				i += len([]rune(dollar)) - 1
+			}
+		case c == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == ';' && endOfLine(runes[i+1:]):
+			stmt := strings.TrimSpace(string(runes[start:i]))
+			if inBlock(stmt) {
+				continue
+			}
+			if stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+	if stmt := strings.TrimSpace(string(runes[start:])); stmt != "" && strings.TrimSpace(stripComments(stmt)) != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+var (
+	compoundHeader = regexp.MustCompile(`(?is)^CREATE\s+(OR\s+REPLACE\s+)?(DEFINER\s*=\s*\S+\s+)?(TEMP(ORARY)?\s+)?(TRIGGER|PROCEDURE|FUNCTION)\b.*\bBEGIN\b`)
+	blockEnd       = regexp.MustCompile(`(?i)\bEND$`)
+	dollarQuote    = regexp.MustCompile(`\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+)
+
+// inBlock indica se stmt é um gatilho, procedimento ou função cujo corpo
+// BEGIN ... END ainda não terminou. Um END seguido de outra palavra, como
+// END IF, não encerra o corpo. Corpos entre $$, do PostgreSQL, já são
+// tratados como strings.
+func inBlock(stmt string) bool {
+	stmt = stripComments(stmt)
+	return compoundHeader.MatchString(stmt) && !blockEnd.MatchString(stmt) && !dollarQuote.MatchString(stmt)
+}
+
+// endOfLine indica se só há espaços, ou um comentário "--", até o fim da
+// linha.
+func endOfLine(rest []rune) bool {
+	for i, c := range rest {
+		switch c {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+		case '-':
+			return i+1 < len(rest) && rest[i+1] == '-'
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{"comandos simples", "CREATE TABLE a (id INT);\n\nCREATE TABLE b (id INT);\n",
+			[]string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"sem ; no fim", "CREATE TABLE a (id INT);\nCREATE TABLE b (id INT)",
+			[]string{"CREATE TABLE a (id INT)", "CREATE TABLE b (id INT)"}},
+		{"; no meio da linha", "INSERT INTO a VALUES (1); INSERT INTO a VALUES (2);\n",
+			[]string{"INSERT INTO a VALUES (1); INSERT INTO a VALUES (2)"}},
+		{"; dentro de strings", "INSERT INTO a VALUES ('x;\n', \"y;\n\", `z;\n`);\nSELECT 1;\n",
+			[]string{"INSERT INTO a VALUES ('x;\n', \"y;\n\", `z;\n`)", "SELECT 1"}},
+		{"aspas escapadas", "INSERT INTO a VALUES ('it''s;\n');\nSELECT 1;\n",
+			[]string{"INSERT INTO a VALUES ('it''s;\n')", "SELECT 1"}},
+		{"comentários", "-- cria a tabela;\nCREATE TABLE a (id INT); -- fim;\n-- só comentário\n",
+			[]string{"-- cria a tabela;\nCREATE TABLE a (id INT)"}},
+		{"gatilho SQLite", "CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (new.id);\n  UPDATE c SET n = n + 1;\nEND;\nSELECT 1;\n",
+			[]string{"CREATE TRIGGER t AFTER INSERT ON a BEGIN\n  INSERT INTO b VALUES (new.id);\n  UPDATE c SET n = n + 1;\nEND", "SELECT 1"}},
+		{"gatilho MySQL com END IF", "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW\nBEGIN\n  IF new.id < 0 THEN\n    SET new.id = 0;\n  END IF;\nend;\n",
+			[]string{"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW\nBEGIN\n  IF new.id < 0 THEN\n    SET new.id = 0;\n  END IF;\nend"}},
+		{"gatilho sem BEGIN", "CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW SET new.id = 1;\nSELECT 1;\n",
+			[]string{"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW SET new.id = 1", "SELECT 1"}},
+		{"função PostgreSQL", "CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n  NEW.n := 1;\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;\nSELECT $1;\n",
+			[]string{"CREATE FUNCTION f() RETURNS trigger AS $$\nBEGIN\n  NEW.n := 1;\n  RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql", "SELECT $1"}},
+		{"dollar quote com tag", "SELECT $body$ a;\n $$ b;\n$body$;\nSELECT 2;\n",
+			[]string{"SELECT $body$ a;\n $$ b;\n$body$", "SELECT 2"}},
+	}
+	for _, tt := range tests {
+		if got := splitStatements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got: %q\nwant: %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSplitStatementsTrigger confere que o gatilho separado é aceito pelo
+// SQLite.
+func TestSplitStatementsTrigger(t *testing.T) {
+	conn, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "trigger.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer conn.Close()
+	script := `CREATE TABLE a (id INTEGER);
+CREATE TABLE b (id INTEGER);
+CREATE TRIGGER copy AFTER INSERT ON a BEGIN
+    INSERT INTO b VALUES (new.id);
+    INSERT INTO b VALUES (-new.id);
+END;
+INSERT INTO a VALUES (7);
+`
+	for _, stmt := range splitStatements(script) {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Exec(%q): %v", stmt, err)
+		}
+	}
+	var n int
+	if err := conn.QueryRow("SELECT COUNT(*) FROM b").Scan(&n); err != nil || n != 2 {
+		t.Errorf("linhas em b = %d, %v; esperado 2", n, err)
+	}
+}
+
+func TestReadMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"m/0010_dez.sql":  {Data: []byte("SELECT 10;")},
+		"m/0002_dois.sql": {Data: []byte("SELECT 2;")},
+		"m/9_nove.sql":    {Data: []byte("SELECT 9;")},
+		"m/0001_um.sql":   {Data: []byte("SELECT 1;")},
+		"m/README.md":     {Data: []byte("# notas")},
+	}
+	migrations, err := readMigrations(fsys, "m")
+	if err != nil {
+		t.Fatalf("readMigrations: %v", err)
+	}
+	var got []string
+	for _, m := range migrations {
+		got = append(got, m.Name)
+	}
+	if want := []string{"um", "dois", "nove", "dez"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ordem = %v, esperado %v", got, want)
+	}
+
+	errorCases := map[string]string{
+		"m/0002_outro.sql": "mesma versão",
+		"m/abc_x.sql":      "inválido",
+		"m/0003.sql":       "inválido",
+		"m/0000_zero.sql":  "inválido",
+	}
+	for file, msg := range errorCases {
+		bad := fstest.MapFS{"m/0002_dois.sql": fsys["m/0002_dois.sql"], file: {Data: []byte("SELECT 1;")}}
+		if _, err := readMigrations(bad, "m"); err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: erro = %v, esperado %q", file, err, msg)
+		}
+	}
+}
+
+// TestEmbeddedMigrations confere que cada dialeto tem as versões 1..n sem
+// buracos, e que todo arquivo se separa em comandos.
+func TestEmbeddedMigrations(t *testing.T) {
+	for _, dialect := range []Dialect{SQLite, PostgreSQL, MySQL} {
+		migrations, err := loadMigrations(dialect)
+		if err != nil {
+			t.Fatalf("%s: %v", dialect.Name(), err)
+		}
+		for i, m := range migrations {
+			if m.Version != i+1 {
+				t.Errorf("%s: migração %d tem a versão %d", dialect.Name(), i+1, m.Version)
+			}
+			if len(splitStatements(m.SQL)) == 0 {
+				t.Errorf("%s: %04d_%s sem comandos", dialect.Name(), m.Version, m.Name)
+			}
+		}
+	}
+}
+
+// legacySchema é o SQL que o README mandava executar antes das migrações.
+const legacySchema = `CREATE TABLE movies (
+    id INTEGER PRIMARY KEY, title TEXT NOT NULL, overview TEXT, release_date TEXT, poster_path TEXT,
+    backdrop_path TEXT, vote_average REAL, trailer_url TEXT, popularity REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+CREATE TABLE tv_shows (
+    id INTEGER PRIMARY KEY, name TEXT NOT NULL, overview TEXT, first_air_date TEXT, poster_path TEXT,
+    backdrop_path TEXT, vote_average REAL, trailer_url TEXT, popularity REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+CREATE TABLE genres (id INTEGER PRIMARY KEY, name TEXT NOT NULL);
+CREATE TABLE movie_genres (movie_id INTEGER, genre_id INTEGER, PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES movies(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE);
+CREATE TABLE tvshow_genres (tvshow_id INTEGER, genre_id INTEGER, PRIMARY KEY (tvshow_id, genre_id),
+    FOREIGN KEY (tvshow_id) REFERENCES tv_shows(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE);
+INSERT INTO movies (id, title) VALUES (1, 'Matrix');
+`
+
+// newLegacyDatabase cria um banco com legacySchema, sem schema_migrations.
+func newLegacyDatabase(t *testing.T) *Database {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "legado.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	for _, stmt := range splitStatements(legacySchema) {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatalf("Exec(%q): %v", stmt, err)
+		}
+	}
+	conn.Close()
+	db, err := NewDatabase(path)
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestMigrateAdoptsLegacySchema(t *testing.T) {
+	db := newLegacyDatabase(t)
+	movie := models.Movie{ID: 2, Title: "Amélie"}
+	err := db.SaveMovie(&movie)
+	if err == nil || !strings.Contains(err.Error(), "Migrate") {
+		t.Fatalf("SaveMovie antes de Migrate = %v, esperado erro pedindo Migrate", err)
+	}
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	if err := db.SaveMovie(&movie); err != nil {
+		t.Fatalf("SaveMovie depois de Migrate: %v", err)
+	}
+	old, err := db.GetMovie(1)
+	if err != nil || old.Title != "Matrix" {
+		t.Errorf("filme criado antes da adoção = %+v, %v", old, err)
+	}
+}
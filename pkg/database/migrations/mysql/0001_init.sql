@@ -0,0 +1,85 @@
+CREATE TABLE IF NOT EXISTS {{movies}} (
+    id INT PRIMARY KEY,
+    title VARCHAR(512) NOT NULL,
+    overview TEXT,
+    release_date VARCHAR(10),
+    poster_path VARCHAR(255),
+    backdrop_path VARCHAR(255),
+    vote_average DOUBLE,
+    trailer_url VARCHAR(255),
+    popularity DOUBLE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS {{tv_shows}} (
+    id INT PRIMARY KEY,
+    name VARCHAR(512) NOT NULL,
+    overview TEXT,
+    first_air_date VARCHAR(10),
+    poster_path VARCHAR(255),
+    backdrop_path VARCHAR(255),
+    vote_average DOUBLE,
+    trailer_url VARCHAR(255),
+    popularity DOUBLE,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS {{genres}} (
+    id INT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {{movie_genres}} (
+    movie_id INT,
+    genre_id INT,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES {{movies}}(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES {{genres}}(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS {{tvshow_genres}} (
+    tvshow_id INT,
+    genre_id INT,
+    PRIMARY KEY (tvshow_id, genre_id),
+    FOREIGN KEY (tvshow_id) REFERENCES {{tv_shows}}(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES {{genres}}(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS {{videos}} (
+    id VARCHAR(64) PRIMARY KEY,
+    media_type VARCHAR(16) NOT NULL,
+    media_id INT NOT NULL,
+    video_key VARCHAR(255) NOT NULL,
+    name VARCHAR(512),
+    site VARCHAR(64),
+    type VARCHAR(64),
+    size INT,
+    official BOOLEAN,
+    published_at VARCHAR(32),
+    iso_639_1 VARCHAR(8),
+    iso_3166_1 VARCHAR(8),
+    INDEX idx_{{videos}}_media (media_type, media_id)
+);
+
+CREATE TABLE IF NOT EXISTS {{countries}} (
+    iso_3166_1 VARCHAR(8) PRIMARY KEY,
+    english_name VARCHAR(255),
+    native_name VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS {{languages}} (
+    iso_639_1 VARCHAR(8) PRIMARY KEY,
+    english_name VARCHAR(255),
+    name VARCHAR(255)
+);
+
+CREATE TABLE IF NOT EXISTS {{images}} (
+    tmdb_path VARCHAR(255) NOT NULL,
+    size VARCHAR(16) NOT NULL,
+    kind VARCHAR(16) NOT NULL,
+    local_path VARCHAR(255) NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    bytes BIGINT,
+    downloaded_at DATETIME,
+    PRIMARY KEY (tmdb_path, size)
+);
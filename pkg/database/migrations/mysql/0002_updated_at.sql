@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN updated_at DATETIME;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN updated_at DATETIME;
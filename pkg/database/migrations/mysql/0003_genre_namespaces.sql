@@ -0,0 +1,40 @@
+-- O nome da chave estrangeira de genre_id foi gerado pelo InnoDB (ou por
+-- quem criou a tabela), então é procurado em information_schema. Sem a
+-- chave, o comando vira DO 0 e pode ser repetido.
+SET @drop_fk = (SELECT COALESCE(MAX(CONCAT('ALTER TABLE `{{movie_genres}}` DROP FOREIGN KEY `', CONSTRAINT_NAME, '`')), 'DO 0')
+    FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '{{movie_genres}}'
+    AND COLUMN_NAME = 'genre_id' AND REFERENCED_TABLE_NAME IS NOT NULL);
+
+PREPARE drop_fk FROM @drop_fk;
+
+EXECUTE drop_fk;
+
+SET @drop_fk = (SELECT COALESCE(MAX(CONCAT('ALTER TABLE `{{tvshow_genres}}` DROP FOREIGN KEY `', CONSTRAINT_NAME, '`')), 'DO 0')
+    FROM information_schema.KEY_COLUMN_USAGE
+    WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = '{{tvshow_genres}}'
+    AND COLUMN_NAME = 'genre_id' AND REFERENCED_TABLE_NAME IS NOT NULL);
+
+PREPARE drop_fk FROM @drop_fk;
+
+EXECUTE drop_fk;
+
+CREATE TABLE {{genres}}_new (
+    id INT NOT NULL,
+    media_type VARCHAR(16) NOT NULL,
+    language VARCHAR(16) NOT NULL DEFAULT '',
+    name VARCHAR(255) NOT NULL,
+    PRIMARY KEY (id, media_type, language)
+);
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'movie', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{movie_genres}}) OR id NOT IN (SELECT genre_id FROM {{tvshow_genres}});
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'tv', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{tvshow_genres}});
+
+DROP TABLE {{genres}};
+
+RENAME TABLE {{genres}}_new TO {{genres}};
@@ -0,0 +1,18 @@
+ALTER TABLE {{movies}} ADD COLUMN original_title VARCHAR(512);
+
+ALTER TABLE {{tv_shows}} ADD COLUMN original_name VARCHAR(512);
+
+CREATE TABLE IF NOT EXISTS {{localized_titles}} (
+    media_type VARCHAR(16) NOT NULL,
+    media_id INT NOT NULL,
+    language VARCHAR(16) NOT NULL,
+    title VARCHAR(512) NOT NULL,
+    PRIMARY KEY (media_type, media_id, language)
+);
+
+CREATE TABLE IF NOT EXISTS {{search_index}} (
+    media_type VARCHAR(16) NOT NULL,
+    media_id INT NOT NULL,
+    document TEXT NOT NULL,
+    PRIMARY KEY (media_type, media_id)
+);
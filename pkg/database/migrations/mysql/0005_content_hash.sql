@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN content_hash CHAR(64);
+
+ALTER TABLE {{tv_shows}} ADD COLUMN content_hash CHAR(64);
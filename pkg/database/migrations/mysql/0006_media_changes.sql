@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS {{media_changes}} (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    media_type VARCHAR(16) NOT NULL,
+    media_id INT NOT NULL,
+    field VARCHAR(64) NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    sync_job VARCHAR(255),
+    changed_at DATETIME NOT NULL,
+    INDEX idx_{{media_changes}}_changed_at (changed_at),
+    INDEX idx_{{media_changes}}_media (media_type, media_id)
+);
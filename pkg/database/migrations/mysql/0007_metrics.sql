@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS {{movie_metrics}} (
+    id INT NOT NULL,
+    date VARCHAR(10) NOT NULL,
+    popularity DOUBLE,
+    vote_average DOUBLE,
+    vote_count INT,
+    PRIMARY KEY (id, date),
+    INDEX idx_{{movie_metrics}}_date (date)
+);
+
+CREATE TABLE IF NOT EXISTS {{tvshow_metrics}} (
+    id INT NOT NULL,
+    date VARCHAR(10) NOT NULL,
+    popularity DOUBLE,
+    vote_average DOUBLE,
+    vote_count INT,
+    PRIMARY KEY (id, date),
+    INDEX idx_{{tvshow_metrics}}_date (date)
+);
@@ -0,0 +1 @@
+ALTER TABLE {{search_index}} ADD FULLTEXT INDEX idx_{{search_index}}_document (document);
@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN updated_at TIMESTAMP;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN updated_at TIMESTAMP;
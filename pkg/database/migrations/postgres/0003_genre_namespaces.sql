@@ -0,0 +1,23 @@
+ALTER TABLE {{movie_genres}} DROP CONSTRAINT IF EXISTS {{movie_genres}}_genre_id_fkey;
+
+ALTER TABLE {{tvshow_genres}} DROP CONSTRAINT IF EXISTS {{tvshow_genres}}_genre_id_fkey;
+
+CREATE TABLE {{genres}}_new (
+    id INTEGER NOT NULL,
+    media_type TEXT NOT NULL,
+    language TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    PRIMARY KEY (id, media_type, language)
+);
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'movie', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{movie_genres}}) OR id NOT IN (SELECT genre_id FROM {{tvshow_genres}});
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'tv', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{tvshow_genres}});
+
+DROP TABLE {{genres}};
+
+ALTER TABLE {{genres}}_new RENAME TO {{genres}};
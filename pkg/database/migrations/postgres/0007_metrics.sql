@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS {{movie_metrics}} (
+    id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    popularity DOUBLE PRECISION,
+    vote_average DOUBLE PRECISION,
+    vote_count INTEGER,
+    PRIMARY KEY (id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_{{movie_metrics}}_date ON {{movie_metrics}} (date);
+
+CREATE TABLE IF NOT EXISTS {{tvshow_metrics}} (
+    id INTEGER NOT NULL,
+    date TEXT NOT NULL,
+    popularity DOUBLE PRECISION,
+    vote_average DOUBLE PRECISION,
+    vote_count INTEGER,
+    PRIMARY KEY (id, date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_{{tvshow_metrics}}_date ON {{tvshow_metrics}} (date);
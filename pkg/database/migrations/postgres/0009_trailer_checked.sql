@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN trailer_checked_at TIMESTAMP;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN trailer_checked_at TIMESTAMP;
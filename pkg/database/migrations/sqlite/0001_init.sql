@@ -0,0 +1,86 @@
+CREATE TABLE IF NOT EXISTS {{movies}} (
+    id INTEGER PRIMARY KEY,
+    title TEXT NOT NULL,
+    overview TEXT,
+    release_date TEXT,
+    poster_path TEXT,
+    backdrop_path TEXT,
+    vote_average REAL,
+    trailer_url TEXT,
+    popularity REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS {{tv_shows}} (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    overview TEXT,
+    first_air_date TEXT,
+    poster_path TEXT,
+    backdrop_path TEXT,
+    vote_average REAL,
+    trailer_url TEXT,
+    popularity REAL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS {{genres}} (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS {{movie_genres}} (
+    movie_id INTEGER,
+    genre_id INTEGER,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES {{movies}}(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES {{genres}}(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS {{tvshow_genres}} (
+    tvshow_id INTEGER,
+    genre_id INTEGER,
+    PRIMARY KEY (tvshow_id, genre_id),
+    FOREIGN KEY (tvshow_id) REFERENCES {{tv_shows}}(id) ON DELETE CASCADE,
+    FOREIGN KEY (genre_id) REFERENCES {{genres}}(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS {{videos}} (
+    id TEXT PRIMARY KEY,
+    media_type TEXT NOT NULL,
+    media_id INTEGER NOT NULL,
+    video_key TEXT NOT NULL,
+    name TEXT,
+    site TEXT,
+    type TEXT,
+    size INTEGER,
+    official BOOLEAN,
+    published_at TEXT,
+    iso_639_1 TEXT,
+    iso_3166_1 TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_{{videos}}_media ON {{videos}} (media_type, media_id);
+
+CREATE TABLE IF NOT EXISTS {{countries}} (
+    iso_3166_1 TEXT PRIMARY KEY,
+    english_name TEXT,
+    native_name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS {{languages}} (
+    iso_639_1 TEXT PRIMARY KEY,
+    english_name TEXT,
+    name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS {{images}} (
+    tmdb_path TEXT NOT NULL,
+    size TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    local_path TEXT NOT NULL,
+    sha256 TEXT NOT NULL,
+    bytes INTEGER,
+    downloaded_at DATETIME,
+    PRIMARY KEY (tmdb_path, size)
+);
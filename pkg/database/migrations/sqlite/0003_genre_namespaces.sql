@@ -0,0 +1,45 @@
+CREATE TABLE {{genres}}_new (
+    id INTEGER NOT NULL,
+    media_type TEXT NOT NULL,
+    language TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    PRIMARY KEY (id, media_type, language)
+);
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'movie', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{movie_genres}}) OR id NOT IN (SELECT genre_id FROM {{tvshow_genres}});
+
+INSERT INTO {{genres}}_new (id, media_type, language, name)
+SELECT id, 'tv', '', name FROM {{genres}}
+WHERE id IN (SELECT genre_id FROM {{tvshow_genres}});
+
+CREATE TABLE {{movie_genres}}_new (
+    movie_id INTEGER,
+    genre_id INTEGER,
+    PRIMARY KEY (movie_id, genre_id),
+    FOREIGN KEY (movie_id) REFERENCES {{movies}}(id) ON DELETE CASCADE
+);
+
+INSERT INTO {{movie_genres}}_new (movie_id, genre_id) SELECT movie_id, genre_id FROM {{movie_genres}};
+
+DROP TABLE {{movie_genres}};
+
+ALTER TABLE {{movie_genres}}_new RENAME TO {{movie_genres}};
+
+CREATE TABLE {{tvshow_genres}}_new (
+    tvshow_id INTEGER,
+    genre_id INTEGER,
+    PRIMARY KEY (tvshow_id, genre_id),
+    FOREIGN KEY (tvshow_id) REFERENCES {{tv_shows}}(id) ON DELETE CASCADE
+);
+
+INSERT INTO {{tvshow_genres}}_new (tvshow_id, genre_id) SELECT tvshow_id, genre_id FROM {{tvshow_genres}};
+
+DROP TABLE {{tvshow_genres}};
+
+ALTER TABLE {{tvshow_genres}}_new RENAME TO {{tvshow_genres}};
+
+DROP TABLE {{genres}};
+
+ALTER TABLE {{genres}}_new RENAME TO {{genres}};
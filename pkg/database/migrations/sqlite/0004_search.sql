@@ -0,0 +1,11 @@
+ALTER TABLE {{movies}} ADD COLUMN original_title TEXT;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN original_name TEXT;
+
+CREATE TABLE IF NOT EXISTS {{localized_titles}} (
+    media_type TEXT NOT NULL,
+    media_id INTEGER NOT NULL,
+    language TEXT NOT NULL,
+    title TEXT NOT NULL,
+    PRIMARY KEY (media_type, media_id, language)
+);
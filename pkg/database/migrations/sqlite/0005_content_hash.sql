@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN content_hash TEXT;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN content_hash TEXT;
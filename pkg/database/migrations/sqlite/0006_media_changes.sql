@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS {{media_changes}} (
+    id INTEGER PRIMARY KEY,
+    media_type TEXT NOT NULL,
+    media_id INTEGER NOT NULL,
+    field TEXT NOT NULL,
+    old_value TEXT,
+    new_value TEXT,
+    sync_job TEXT,
+    changed_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_{{media_changes}}_changed_at ON {{media_changes}} (changed_at);
+
+CREATE INDEX IF NOT EXISTS idx_{{media_changes}}_media ON {{media_changes}} (media_type, media_id);
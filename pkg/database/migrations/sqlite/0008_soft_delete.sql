@@ -0,0 +1,7 @@
+ALTER TABLE {{movies}} ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+ALTER TABLE {{movies}} ADD COLUMN deleted_at DATETIME;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN status TEXT NOT NULL DEFAULT 'active';
+
+ALTER TABLE {{tv_shows}} ADD COLUMN deleted_at DATETIME;
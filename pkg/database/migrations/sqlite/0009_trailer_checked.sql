@@ -0,0 +1,3 @@
+ALTER TABLE {{movies}} ADD COLUMN trailer_checked_at DATETIME;
+
+ALTER TABLE {{tv_shows}} ADD COLUMN trailer_checked_at DATETIME;
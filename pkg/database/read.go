@@ -0,0 +1,623 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// ErrNotFound é devolvido pelas leituras por ID quando a linha não existe.
+var ErrNotFound = errors.New("registro não encontrado")
+
+const defaultListLimit = 20
+
+// ListFilter filtra e ordena ListMovies e ListTVShows. Campos zerados não
+// filtram.
+type ListFilter struct {
+	// GenreIDs mantém itens com pelo menos um dos gêneros.
+	GenreIDs []int
+	// YearFrom e YearTo limitam o ano de lançamento (filmes) ou de estreia
+	// (séries), inclusive.
+	YearFrom int
+	YearTo   int
+	MinVote  float64
+	// SortField aceita "popularity" (padrão), "vote_average", "release_date",
+	// "title", "id", "created_at" e "updated_at". Para séries,
+	// "release_date" e "title" usam first_air_date e name.
+	SortField string
+	// SortDirection aceita "desc" (padrão) ou "asc".
+	SortDirection string
+	// Limit padrão é 20.
+	Limit  int
+	Offset int
+	// Cursor continua a partir do NextCursor de uma página anterior com o
+	// mesmo filtro. Quando informado, Offset é ignorado.
+	Cursor string
+	// IncludeDeleted inclui os itens marcados como removidos do TMDB.
+	IncludeDeleted bool
+}
+
+type MoviePage struct {
+	Movies []models.Movie
+	// NextCursor é vazio quando não há mais páginas.
+	NextCursor string
+}
+
+type TVShowPage struct {
+	Shows      []models.TVShow
+	NextCursor string
+}
+
+// mediaTable descreve as diferenças entre as tabelas de filmes e séries
+// usadas pelas leituras. table e genreTable são nomes lógicos, convertidos
+// por Database.table.
+type mediaTable struct {
+	mediaType   string
+	table       string
+	titleCol    string
+	originalCol string
+	dateCol     string
+	genreTable  string
+	genreFK     string
+	metrics     string
+}
+
+var (
+	moviesTable = mediaTable{models.MediaTypeMovie, "movies", "title", "original_title", "release_date",
+		"movie_genres", "movie_id", "movie_metrics"}
+	tvShowsTable = mediaTable{models.MediaTypeTV, "tv_shows", "name", "original_name", "first_air_date",
+		"tvshow_genres", "tvshow_id", "tvshow_metrics"}
+)
+
+func (t mediaTable) selectColumns() string {
+	return strings.Join([]string{
+		"id",
+		"COALESCE(" + t.titleCol + ", '')",
+		"COALESCE(" + t.originalCol + ", '')",
+		"COALESCE(overview, '')",
+		"COALESCE(" + t.dateCol + ", '')",
+		"COALESCE(poster_path, '')",
+		"COALESCE(backdrop_path, '')",
+		"COALESCE(vote_average, 0)",
+		"COALESCE(trailer_url, '')",
+		"COALESCE(popularity, 0)",
+		"updated_at",
+		"created_at",
+		"COALESCE(status, 'active')",
+		"deleted_at",
+	}, ", ")
+}
+
+// nullTime substitui created_at e updated_at nulos na ordenação e nos
+// cursores. Sem ele, as linhas gravadas antes da migração 0002 (com
+// updated_at nulo) nunca satisfariam as comparações do cursor e seriam
+// puladas pela paginação.
+var nullTime = time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// nullTimeLiteral escreve nullTime no formato em que o driver de cada banco
+// grava e compara datas.
+func nullTimeLiteral(d Dialect) string {
+	switch d {
+	case PostgreSQL, MySQL:
+		return "TIMESTAMP '1970-01-01 00:00:00'"
+	}
+	return "'1970-01-01 00:00:00+00:00'"
+}
+
+// orNullTime devolve nullTime para datas zeradas, que é como as datas nulas
+// chegam aos modelos.
+func orNullTime(t time.Time) time.Time {
+	if t.IsZero() {
+		return nullTime
+	}
+	return t
+}
+
+func (t mediaTable) sortColumn(field string, dialect Dialect) (string, error) {
+	switch field {
+	case "", "popularity":
+		return "COALESCE(popularity, 0)", nil
+	case "vote_average":
+		return "COALESCE(vote_average, 0)", nil
+	case "release_date":
+		return "COALESCE(" + t.dateCol + ", '')", nil
+	case "title":
+		return "COALESCE(" + t.titleCol + ", '')", nil
+	case "created_at", "updated_at":
+		return "COALESCE(" + field + ", " + nullTimeLiteral(dialect) + ")", nil
+	case "id":
+		return field, nil
+	}
+	return "", fmt.Errorf("campo de ordenação inválido: %q", field)
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMovie(row scanner) (models.Movie, error) {
+	var m models.Movie
+	var updatedAt, createdAt, deletedAt sql.NullTime
+	err := row.Scan(&m.ID, &m.Title, &m.OriginalTitle, &m.Overview, &m.ReleaseDate, &m.PosterPath, &m.BackdropPath,
+		&m.VoteAverage, &m.TrailerURL, &m.Popularity, &updatedAt, &createdAt, &m.Status, &deletedAt)
+	m.UpdatedAt = updatedAt.Time
+	m.CreatedAt = createdAt.Time
+	if deletedAt.Valid {
+		m.DeletedAt = &deletedAt.Time
+	}
+	return m, err
+}
+
+func scanTVShow(row scanner) (models.TVShow, error) {
+	var s models.TVShow
+	var updatedAt, createdAt, deletedAt sql.NullTime
+	err := row.Scan(&s.ID, &s.Name, &s.OriginalName, &s.Overview, &s.FirstAirDate, &s.PosterPath, &s.BackdropPath,
+		&s.VoteAverage, &s.TrailerURL, &s.Popularity, &updatedAt, &createdAt, &s.Status, &deletedAt)
+	s.UpdatedAt = updatedAt.Time
+	s.CreatedAt = createdAt.Time
+	if deletedAt.Valid {
+		s.DeletedAt = &deletedAt.Time
+	}
+	return s, err
+}
+
+func (d *Database) GetMovie(id int) (*models.Movie, error) {
+	return d.GetMovieContext(context.Background(), id)
+}
+
+func (d *Database) GetMovieContext(ctx context.Context, id int) (*models.Movie, error) {
+	movies, err := d.GetMoviesByIDsContext(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, ErrNotFound
+	}
+	return &movies[0], nil
+}
+
+// GetMoviesByIDs devolve os filmes encontrados na ordem dos IDs informados.
+// IDs inexistentes são ignorados.
+func (d *Database) GetMoviesByIDs(ids []int) ([]models.Movie, error) {
+	return d.GetMoviesByIDsContext(context.Background(), ids)
+}
+
+func (d *Database) GetMoviesByIDsContext(ctx context.Context, ids []int) ([]models.Movie, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var movies []models.Movie
+	for _, chunk := range chunkIDs(ids) {
+		query := "SELECT " + moviesTable.selectColumns() + " FROM " + d.table("movies") + " WHERE id IN (" + placeholders(len(chunk)) + ")"
+		found, err := d.queryMovies(ctx, query, intArgs(chunk)...)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, found...)
+	}
+	byID := make(map[int]models.Movie, len(movies))
+	for _, m := range movies {
+		byID[m.ID] = m
+	}
+	ordered := make([]models.Movie, 0, len(movies))
+	for _, id := range ids {
+		if m, ok := byID[id]; ok {
+			ordered = append(ordered, m)
+			delete(byID, id)
+		}
+	}
+	return ordered, nil
+}
+
+func (d *Database) ListMovies(filter ListFilter) (*MoviePage, error) {
+	return d.ListMoviesContext(context.Background(), filter)
+}
+
+func (d *Database) ListMoviesContext(ctx context.Context, filter ListFilter) (*MoviePage, error) {
+	query, args, limit, err := d.listQuery(moviesTable, filter)
+	if err != nil {
+		return nil, err
+	}
+	movies, err := d.queryMovies(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	page := &MoviePage{Movies: movies}
+	if len(movies) > limit {
+		page.Movies = movies[:limit]
+		last := page.Movies[limit-1]
+		page.NextCursor, err = movieCursor(filter.SortField, last)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+func (d *Database) GetTVShow(id int) (*models.TVShow, error) {
+	return d.GetTVShowContext(context.Background(), id)
+}
+
+func (d *Database) GetTVShowContext(ctx context.Context, id int) (*models.TVShow, error) {
+	shows, err := d.GetTVShowsByIDsContext(ctx, []int{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(shows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &shows[0], nil
+}
+
+// GetTVShowsByIDs devolve as séries encontradas na ordem dos IDs
+// informados. IDs inexistentes são ignorados.
+func (d *Database) GetTVShowsByIDs(ids []int) ([]models.TVShow, error) {
+	return d.GetTVShowsByIDsContext(context.Background(), ids)
+}
+
+func (d *Database) GetTVShowsByIDsContext(ctx context.Context, ids []int) ([]models.TVShow, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var shows []models.TVShow
+	for _, chunk := range chunkIDs(ids) {
+		query := "SELECT " + tvShowsTable.selectColumns() + " FROM " + d.table("tv_shows") + " WHERE id IN (" + placeholders(len(chunk)) + ")"
+		found, err := d.queryTVShows(ctx, query, intArgs(chunk)...)
+		if err != nil {
+			return nil, err
+		}
+		shows = append(shows, found...)
+	}
+	byID := make(map[int]models.TVShow, len(shows))
+	for _, s := range shows {
+		byID[s.ID] = s
+	}
+	ordered := make([]models.TVShow, 0, len(shows))
+	for _, id := range ids {
+		if s, ok := byID[id]; ok {
+			ordered = append(ordered, s)
+			delete(byID, id)
+		}
+	}
+	return ordered, nil
+}
+
+func (d *Database) ListTVShows(filter ListFilter) (*TVShowPage, error) {
+	return d.ListTVShowsContext(context.Background(), filter)
+}
+
+func (d *Database) ListTVShowsContext(ctx context.Context, filter ListFilter) (*TVShowPage, error) {
+	query, args, limit, err := d.listQuery(tvShowsTable, filter)
+	if err != nil {
+		return nil, err
+	}
+	shows, err := d.queryTVShows(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	page := &TVShowPage{Shows: shows}
+	if len(shows) > limit {
+		page.Shows = shows[:limit]
+		last := page.Shows[limit-1]
+		page.NextCursor, err = tvShowCursor(filter.SortField, last)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// ListGenres devolve todos os gêneros de todos os tipos e idiomas.
+func (d *Database) ListGenres() ([]models.Genre, error) {
+	return d.ListGenresContext(context.Background())
+}
+
+func (d *Database) ListGenresContext(ctx context.Context) ([]models.Genre, error) {
+	return d.queryGenres(ctx, `SELECT id, media_type, language, name FROM `+d.table("genres")+` ORDER BY media_type, language, name`)
+}
+
+// ListGenresFor devolve os gêneros de um tipo de mídia (models.MediaTypeMovie
+// ou models.MediaTypeTV) em um idioma. Idioma vazio devolve todos os
+// idiomas.
+func (d *Database) ListGenresFor(mediaType, language string) ([]models.Genre, error) {
+	return d.ListGenresForContext(context.Background(), mediaType, language)
+}
+
+func (d *Database) ListGenresForContext(ctx context.Context, mediaType, language string) ([]models.Genre, error) {
+	if language == "" {
+		return d.queryGenres(ctx, d.dialect.Rebind(`SELECT id, media_type, language, name FROM `+d.table("genres")+` 
+			WHERE media_type = ? ORDER BY language, name`), mediaType)
+	}
+	return d.queryGenres(ctx, d.dialect.Rebind(`SELECT id, media_type, language, name FROM `+d.table("genres")+` 
+		WHERE media_type = ? AND language = ? ORDER BY name`), mediaType, language)
+}
+
+func (d *Database) GetGenre(mediaType, language string, id int) (*models.Genre, error) {
+	return d.GetGenreContext(context.Background(), mediaType, language, id)
+}
+
+func (d *Database) GetGenreContext(ctx context.Context, mediaType, language string, id int) (*models.Genre, error) {
+	genres, err := d.queryGenres(ctx, d.dialect.Rebind(`SELECT id, media_type, language, name FROM `+d.table("genres")+` 
+		WHERE media_type = ? AND language = ? AND id = ?`), mediaType, language, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(genres) == 0 {
+		return nil, ErrNotFound
+	}
+	return &genres[0], nil
+}
+
+func (d *Database) queryGenres(ctx context.Context, query string, args ...interface{}) ([]models.Genre, error) {
+	rows, err := d.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var genres []models.Genre
+	for rows.Next() {
+		var g models.Genre
+		if err := rows.Scan(&g.ID, &g.MediaType, &g.Language, &g.Name); err != nil {
+			return nil, err
+		}
+		genres = append(genres, g)
+	}
+	return genres, rows.Err()
+}
+
+// listQuery monta a consulta de listagem. Uma linha a mais que o limite é
+// pedida para saber se existe próxima página.
+func (d *Database) listQuery(t mediaTable, f ListFilter) (string, []interface{}, int, error) {
+	sortCol, err := t.sortColumn(f.SortField, d.dialect)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	desc := true
+	switch strings.ToLower(f.SortDirection) {
+	case "", "desc":
+	case "asc":
+		desc = false
+	default:
+		return "", nil, 0, fmt.Errorf("direção de ordenação inválida: %q", f.SortDirection)
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var where []string
+	var args []interface{}
+	if !f.IncludeDeleted {
+		where = append(where, "deleted_at IS NULL")
+	}
+	if len(f.GenreIDs) > 0 {
+		where = append(where, fmt.Sprintf("id IN (SELECT %s FROM %s WHERE genre_id IN (%s))",
+			t.genreFK, d.table(t.genreTable), placeholders(len(f.GenreIDs))))
+		args = append(args, intArgs(f.GenreIDs)...)
+	}
+	if f.YearFrom > 0 {
+		where = append(where, t.dateCol+" >= ?")
+		args = append(args, fmt.Sprintf("%04d-01-01", f.YearFrom))
+	}
+	if f.YearTo > 0 {
+		where = append(where, t.dateCol+" <= ?")
+		args = append(args, fmt.Sprintf("%04d-12-31", f.YearTo))
+	}
+	if f.MinVote > 0 {
+		where = append(where, "vote_average >= ?")
+		args = append(args, f.MinVote)
+	}
+	if f.Cursor != "" {
+		value, id, err := decodeCursor(f.Cursor, f.SortField)
+		if err != nil {
+			return "", nil, 0, err
+		}
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", sortCol, op, sortCol, op))
+		args = append(args, value, value, id)
+	}
+
+	query := "SELECT " + t.selectColumns() + " FROM " + d.table(t.table)
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	dir := "ASC"
+	if desc {
+		dir = "DESC"
+	}
+	query += fmt.Sprintf(" ORDER BY %s %s, id %s LIMIT ?", sortCol, dir, dir)
+	args = append(args, limit+1)
+	if f.Cursor == "" && f.Offset > 0 {
+		query += " OFFSET ?"
+		args = append(args, f.Offset)
+	}
+	return query, args, limit, nil
+}
+
+func (d *Database) queryMovies(ctx context.Context, query string, args ...interface{}) ([]models.Movie, error) {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var movies []models.Movie
+	for rows.Next() {
+		m, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(movies))
+	for i, m := range movies {
+		ids[i] = m.ID
+	}
+	genres, err := d.genreIDsFor(ctx, moviesTable, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range movies {
+		movies[i].GenreIDs = genres[movies[i].ID]
+	}
+	return movies, nil
+}
+
+func (d *Database) queryTVShows(ctx context.Context, query string, args ...interface{}) ([]models.TVShow, error) {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var shows []models.TVShow
+	for rows.Next() {
+		s, err := scanTVShow(rows)
+		if err != nil {
+			return nil, err
+		}
+		shows = append(shows, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, len(shows))
+	for i, s := range shows {
+		ids[i] = s.ID
+	}
+	genres, err := d.genreIDsFor(ctx, tvShowsTable, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range shows {
+		shows[i].GenreIDs = genres[shows[i].ID]
+	}
+	return shows, nil
+}
+
+// genreIDsFor carrega, em uma consulta, os gêneros dos itens informados.
+func (d *Database) genreIDsFor(ctx context.Context, t mediaTable, ids []int) (map[int][]int, error) {
+	result := make(map[int][]int, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	query := fmt.Sprintf("SELECT %s, genre_id FROM %s WHERE %s IN (%s) ORDER BY %s, genre_id",
+		t.genreFK, d.table(t.genreTable), t.genreFK, placeholders(len(ids)), t.genreFK)
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, genreID int
+		if err := rows.Scan(&id, &genreID); err != nil {
+			return nil, err
+		}
+		result[id] = append(result[id], genreID)
+	}
+	return result, rows.Err()
+}
+
+// O cursor guarda o valor da coluna de ordenação e o ID do último item da
+// página, codificados em base64 para serem tratados como opacos.
+type cursor struct {
+	Value interface{} `json:"v"`
+	ID    int         `json:"id"`
+}
+
+func movieCursor(field string, m models.Movie) (string, error) {
+	return encodeCursor(field, m.ID, m.Popularity, m.VoteAverage, m.ReleaseDate, m.Title, m.CreatedAt, m.UpdatedAt)
+}
+
+func tvShowCursor(field string, s models.TVShow) (string, error) {
+	return encodeCursor(field, s.ID, s.Popularity, s.VoteAverage, s.FirstAirDate, s.Name, s.CreatedAt, s.UpdatedAt)
+}
+
+func encodeCursor(field string, id int, popularity, vote float64, date, title string, createdAt, updatedAt time.Time) (string, error) {
+	var value interface{}
+	switch field {
+	case "", "popularity":
+		value = popularity
+	case "vote_average":
+		value = vote
+	case "release_date":
+		value = date
+	case "title":
+		value = title
+	case "id":
+		value = id
+	case "created_at":
+		value = orNullTime(createdAt)
+	case "updated_at":
+		value = orNullTime(updatedAt)
+	default:
+		return "", fmt.Errorf("campo de ordenação inválido: %q", field)
+	}
+	data, err := json.Marshal(cursor{Value: value, ID: id})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor devolve o valor já no tipo da coluna de ordenação, para que
+// o driver o formate da mesma forma que os valores gravados.
+func decodeCursor(s, field string) (interface{}, int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, 0, fmt.Errorf("cursor inválido: %v", err)
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, 0, fmt.Errorf("cursor inválido: %v", err)
+	}
+	switch v := c.Value.(type) {
+	case float64:
+		if field == "id" {
+			return int(v), c.ID, nil
+		}
+	case string:
+		if field == "created_at" || field == "updated_at" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return nil, 0, fmt.Errorf("cursor inválido: %v", err)
+			}
+			return t, c.ID, nil
+		}
+	}
+	return c.Value, c.ID, nil
+}
+
+// maxInIDs limita os IDs de cada cláusula IN (...): o SQLite anterior à
+// versão 3.32 aceita no máximo 999 parâmetros por comando.
+const maxInIDs = 500
+
+// chunkIDs divide ids em partes de até maxInIDs.
+func chunkIDs(ids []int) [][]int {
+	var chunks [][]int
+	for start := 0; start < len(ids); start += maxInIDs {
+		chunks = append(chunks, ids[start:min(start+maxInIDs, len(ids))])
+	}
+	return chunks
+}
+
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
@@ -0,0 +1,223 @@
+package database
+
+// schemaTable descreve uma tabela esperada por Verify.
+type schemaTable struct {
+	name        string // nome lógico
+	columns     []schemaColumn
+	primaryKey  []string
+	indexes     []schemaIndex
+	foreignKeys []schemaForeignKey
+	// sqliteVirtual marca o índice de busca, que no SQLite é uma tabela
+	// virtual criada por Migrate fora dos arquivos de migração.
+	sqliteVirtual bool
+}
+
+type schemaColumn struct {
+	name string
+	// Tipos no SQLite, PostgreSQL e MySQL.
+	sqlite, postgres, mysql string
+}
+
+type schemaIndex struct {
+	name    string // sufixo: o índice se chama idx_<tabela>_<name>
+	columns []string
+	// postgresUsing define o método do índice no PostgreSQL (ex.: GIN).
+	postgresUsing string
+	// mysqlKind define o tipo do índice no MySQL (ex.: FULLTEXT).
+	mysqlKind string
+	// notSQLite indica um índice que não existe no SQLite, onde o índice de
+	// busca é uma tabela virtual.
+	notSQLite bool
+}
+
+type schemaForeignKey struct {
+	column   string
+	refTable string // nome lógico
+}
+
+func (c schemaColumn) typeFor(d Dialect) string {
+	switch d {
+	case PostgreSQL:
+		return c.postgres
+	case MySQL:
+		return c.mysql
+	}
+	return c.sqlite
+}
+
+func col(name, sqlite, postgres, mysql string) schemaColumn {
+	return schemaColumn{name, sqlite, postgres, mysql}
+}
+
+// Tipos repetidos nas tabelas.
+func textCol(name, mysql string) schemaColumn { return col(name, "TEXT", "TEXT", mysql) }
+func intCol(name string) schemaColumn         { return col(name, "INTEGER", "INTEGER", "INT") }
+func realCol(name string) schemaColumn        { return col(name, "REAL", "DOUBLE PRECISION", "DOUBLE") }
+func timeCol(name string) schemaColumn        { return col(name, "DATETIME", "TIMESTAMP", "DATETIME") }
+
+func mediaColumns(title, original, date string) []schemaColumn {
+	return []schemaColumn{
+		intCol("id"),
+		col(title, "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(512) NOT NULL"),
+		textCol(original, "VARCHAR(512)"),
+		textCol("overview", "TEXT"),
+		textCol(date, "VARCHAR(10)"),
+		textCol("poster_path", "VARCHAR(255)"),
+		textCol("backdrop_path", "VARCHAR(255)"),
+		realCol("vote_average"),
+		textCol("trailer_url", "VARCHAR(255)"),
+		realCol("popularity"),
+		textCol("content_hash", "CHAR(64)"),
+		col("status", "TEXT NOT NULL DEFAULT 'active'", "TEXT NOT NULL DEFAULT 'active'", "VARCHAR(16) NOT NULL DEFAULT 'active'"),
+		timeCol("deleted_at"),
+		timeCol("updated_at"),
+		col("created_at", "DATETIME DEFAULT CURRENT_TIMESTAMP", "TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+			"DATETIME DEFAULT CURRENT_TIMESTAMP"),
+		timeCol("trailer_checked_at"),
+	}
+}
+
+// expectedSchema descreve o esquema resultante de todas as migrações e é a
+// referência de Verify. Toda migração nova que mude tabelas, colunas,
+// índices ou chaves estrangeiras precisa ser refletida aqui.
+var expectedSchema = []schemaTable{
+	{
+		name:       "movies",
+		columns:    mediaColumns("title", "original_title", "release_date"),
+		primaryKey: []string{"id"},
+	},
+	{
+		name:       "tv_shows",
+		columns:    mediaColumns("name", "original_name", "first_air_date"),
+		primaryKey: []string{"id"},
+	},
+	{
+		name: "genres",
+		columns: []schemaColumn{
+			intCol("id"),
+			col("media_type", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("language", "TEXT NOT NULL DEFAULT ''", "TEXT NOT NULL DEFAULT ''", "VARCHAR(16) NOT NULL DEFAULT ''"),
+			col("name", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(255) NOT NULL"),
+		},
+		primaryKey: []string{"id", "media_type", "language"},
+	},
+	{
+		name:        "movie_genres",
+		columns:     []schemaColumn{intCol("movie_id"), intCol("genre_id")},
+		primaryKey:  []string{"movie_id", "genre_id"},
+		foreignKeys: []schemaForeignKey{{"movie_id", "movies"}},
+	},
+	{
+		name:        "tvshow_genres",
+		columns:     []schemaColumn{intCol("tvshow_id"), intCol("genre_id")},
+		primaryKey:  []string{"tvshow_id", "genre_id"},
+		foreignKeys: []schemaForeignKey{{"tvshow_id", "tv_shows"}},
+	},
+	{
+		name: "videos",
+		columns: []schemaColumn{
+			textCol("id", "VARCHAR(64)"),
+			col("media_type", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("media_id", "INTEGER NOT NULL", "INTEGER NOT NULL", "INT NOT NULL"),
+			col("video_key", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(255) NOT NULL"),
+			textCol("name", "VARCHAR(512)"),
+			textCol("site", "VARCHAR(64)"),
+			textCol("type", "VARCHAR(64)"),
+			intCol("size"),
+			col("official", "BOOLEAN", "BOOLEAN", "BOOLEAN"),
+			textCol("published_at", "VARCHAR(32)"),
+			textCol("iso_639_1", "VARCHAR(8)"),
+			textCol("iso_3166_1", "VARCHAR(8)"),
+		},
+		primaryKey: []string{"id"},
+		indexes:    []schemaIndex{{name: "media", columns: []string{"media_type", "media_id"}}},
+	},
+	{
+		name: "countries",
+		columns: []schemaColumn{
+			textCol("iso_3166_1", "VARCHAR(8)"),
+			textCol("english_name", "VARCHAR(255)"),
+			textCol("native_name", "VARCHAR(255)"),
+		},
+		primaryKey: []string{"iso_3166_1"},
+	},
+	{
+		name: "languages",
+		columns: []schemaColumn{
+			textCol("iso_639_1", "VARCHAR(8)"),
+			textCol("english_name", "VARCHAR(255)"),
+			textCol("name", "VARCHAR(255)"),
+		},
+		primaryKey: []string{"iso_639_1"},
+	},
+	{
+		name: "images",
+		columns: []schemaColumn{
+			col("tmdb_path", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(255) NOT NULL"),
+			col("size", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("kind", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("local_path", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(255) NOT NULL"),
+			col("sha256", "TEXT NOT NULL", "TEXT NOT NULL", "CHAR(64) NOT NULL"),
+			col("bytes", "INTEGER", "BIGINT", "BIGINT"),
+			timeCol("downloaded_at"),
+		},
+		primaryKey: []string{"tmdb_path", "size"},
+	},
+	{
+		name: "localized_titles",
+		columns: []schemaColumn{
+			col("media_type", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("media_id", "INTEGER NOT NULL", "INTEGER NOT NULL", "INT NOT NULL"),
+			col("language", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("title", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(512) NOT NULL"),
+		},
+		primaryKey: []string{"media_type", "media_id", "language"},
+	},
+	{
+		name: "search_index",
+		columns: []schemaColumn{
+			col("media_type", "", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("media_id", "", "INTEGER NOT NULL", "INT NOT NULL"),
+			col("document", "", "TSVECTOR NOT NULL", "TEXT NOT NULL"),
+		},
+		primaryKey: []string{"media_type", "media_id"},
+		indexes: []schemaIndex{{name: "document", columns: []string{"document"},
+			postgresUsing: "GIN", mysqlKind: "FULLTEXT", notSQLite: true}},
+		sqliteVirtual: true,
+	},
+	{
+		name: "media_changes",
+		columns: []schemaColumn{
+			col("id", "INTEGER", "BIGSERIAL", "BIGINT AUTO_INCREMENT"),
+			col("media_type", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(16) NOT NULL"),
+			col("media_id", "INTEGER NOT NULL", "INTEGER NOT NULL", "INT NOT NULL"),
+			col("field", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(64) NOT NULL"),
+			textCol("old_value", "TEXT"),
+			textCol("new_value", "TEXT"),
+			textCol("sync_job", "VARCHAR(255)"),
+			col("changed_at", "DATETIME NOT NULL", "TIMESTAMP NOT NULL", "DATETIME NOT NULL"),
+		},
+		primaryKey: []string{"id"},
+		indexes: []schemaIndex{
+			{name: "changed_at", columns: []string{"changed_at"}},
+			{name: "media", columns: []string{"media_type", "media_id"}},
+		},
+	},
+	metricsSchema("movie_metrics"),
+	metricsSchema("tvshow_metrics"),
+}
+
+func metricsSchema(name string) schemaTable {
+	return schemaTable{
+		name: name,
+		columns: []schemaColumn{
+			col("id", "INTEGER NOT NULL", "INTEGER NOT NULL", "INT NOT NULL"),
+			col("date", "TEXT NOT NULL", "TEXT NOT NULL", "VARCHAR(10) NOT NULL"),
+			realCol("popularity"),
+			realCol("vote_average"),
+			intCol("vote_count"),
+		},
+		primaryKey: []string{"id", "date"},
+		indexes:    []schemaIndex{{name: "date", columns: []string{"date"}}},
+	}
+}
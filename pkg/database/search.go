@@ -0,0 +1,440 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// O índice de busca fica na tabela search_index, uma linha por filme ou
+// série, com título, título original, sinopse e títulos localizados.
+//
+//   - SQLite: tabela virtual FTS5 (ou FTS4, quando o driver não foi
+//     compilado com FTS5) com o tokenizador unicode61 remove_diacritics=2,
+//     que ignora acentos. Criada por Migrate, já que a escolha depende do
+//     driver.
+//   - PostgreSQL: coluna tsvector com configuração "simple" e acentos
+//     removidos por translate().
+//   - MySQL: texto concatenado com índice FULLTEXT, pesquisado com
+//     MATCH ... AGAINST; as collations padrão já ignoram acentos.
+//
+// As linhas são atualizadas pelos métodos de save na mesma transação, por
+// isso Migrate é obrigatório antes de gravar (ver Database.WithTx).
+
+// SearchOptions limita e pagina Search.
+type SearchOptions struct {
+	// MediaType restringe a models.MediaTypeMovie ou models.MediaTypeTV.
+	// Vazio busca ambos.
+	MediaType string
+	// Limit padrão é 20.
+	Limit  int
+	Offset int
+}
+
+type SearchResult struct {
+	MediaType string
+	ID        int
+	// Score é maior para resultados mais relevantes.
+	Score float64
+	// Movie ou Show é preenchido conforme MediaType.
+	Movie *models.Movie
+	Show  *models.TVShow
+}
+
+// Letras acentuadas usadas em translate() no PostgreSQL.
+const (
+	accentedChars   = "áàâãäåéèêëíìîïóòôõöúùûüçñý"
+	unaccentedChars = "aaaaaaeeeeiiiiooooouuuucny"
+)
+
+// Rowids do SQLite: filmes em pares e séries em ímpares, para que filmes e
+// séries com o mesmo ID não colidam no índice.
+func (t mediaTable) rowIDExpr() string {
+	if t.mediaType == models.MediaTypeTV {
+		return "id * 2 + 1"
+	}
+	return "id * 2"
+}
+
+// refreshSearchSQL devolve o comando que regrava no índice os itens da
+// tabela que atendem a where. Itens removidos ficam fora do índice.
+func (d *Database) refreshSearchSQL(t mediaTable, where string) string {
+	index, titles, table := d.table("search_index"), d.table("localized_titles"), d.table(t.table)
+	where = "(" + where + ") AND deleted_at IS NULL"
+	switch d.dialect {
+	case PostgreSQL:
+		fold := func(expr string) string {
+			return fmt.Sprintf("translate(lower(%s), '%s', '%s')", expr, accentedChars, unaccentedChars)
+		}
+		allTitles := fmt.Sprintf("concat_ws(' ', %s, %s, (SELECT string_agg(l.title, ' ') FROM %s l "+
+			"WHERE l.media_type = '%s' AND l.media_id = %s.id))", t.titleCol, t.originalCol, titles, t.mediaType, table)
+		return d.dialect.Rebind(fmt.Sprintf(`INSERT INTO %s (media_type, media_id, document)
+			SELECT '%s', id, setweight(to_tsvector('simple', %s), 'A') || setweight(to_tsvector('simple', %s), 'C')
+			FROM %s WHERE %s
+			ON CONFLICT (media_type, media_id) DO UPDATE SET document = excluded.document`,
+			index, t.mediaType, fold(allTitles), fold("coalesce(overview, '')"), table, where))
+	case MySQL:
+		return fmt.Sprintf(`INSERT INTO %s (media_type, media_id, document)
+			SELECT '%s', id, CONCAT_WS(' ', %s, %s, (SELECT GROUP_CONCAT(l.title SEPARATOR ' ') FROM %s l
+				WHERE l.media_type = '%s' AND l.media_id = %s.id), overview)
+			FROM %s WHERE %s
+			ON DUPLICATE KEY UPDATE document = VALUES(document)`,
+			index, t.mediaType, t.titleCol, t.originalCol, titles, t.mediaType, table, table, where)
+	}
+	return fmt.Sprintf(`INSERT OR REPLACE INTO %s
+		(rowid, media_type, media_id, title, original_title, overview, localized_titles)
+		SELECT %s, '%s', id, COALESCE(%s, ''), COALESCE(%s, ''), COALESCE(overview, ''),
+			COALESCE((SELECT group_concat(l.title, ' ') FROM %s l
+				WHERE l.media_type = '%s' AND l.media_id = %s.id), '')
+		FROM %s WHERE %s`,
+		index, t.rowIDExpr(), t.mediaType, t.titleCol, t.originalCol, titles, t.mediaType, table, table, where)
+}
+
+// ensureSearchIndex cria o índice do SQLite e o preenche quando está vazio,
+// o que acontece logo após criá-lo ou migrar um banco existente.
+func (d *Database) ensureSearchIndex(ctx context.Context) error {
+	if d.dialect == SQLite {
+		if err := d.createSQLiteSearchIndex(ctx); err != nil {
+			return err
+		}
+	}
+	var n int
+	if err := d.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+d.table("search_index")).Scan(&n); err != nil {
+		return err
+	}
+	if n > 0 {
+		return nil
+	}
+	for _, t := range []mediaTable{moviesTable, tvShowsTable} {
+		if _, err := d.db.ExecContext(ctx, d.refreshSearchSQL(t, "1 = 1")); err != nil {
+			return fmt.Errorf("erro ao preencher o índice de busca: %v", err)
+		}
+	}
+	return nil
+}
+
+func (d *Database) createSQLiteSearchIndex(ctx context.Context) error {
+	index := d.table("search_index")
+	exists, err := d.tableExists(ctx, index)
+	if err != nil || exists {
+		return err
+	}
+	ddl, err := d.sqliteSearchIndexDDL(ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := d.db.ExecContext(ctx, ddl); err != nil {
+		return fmt.Errorf("erro ao criar o índice de busca: %v", err)
+	}
+	return nil
+}
+
+// sqliteSearchIndexDDL monta o CREATE VIRTUAL TABLE do índice, com FTS5
+// quando o driver oferece e FTS4 caso contrário.
+func (d *Database) sqliteSearchIndexDDL(ctx context.Context) (string, error) {
+	var fts5 bool
+	if err := d.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&fts5); err != nil {
+		return "", err
+	}
+	index := d.table("search_index")
+	if fts5 {
+		return `CREATE VIRTUAL TABLE ` + index + ` USING fts5(
+			media_type UNINDEXED, media_id UNINDEXED, title, original_title, overview, localized_titles,
+			tokenize = 'unicode61 remove_diacritics 2')`, nil
+	}
+	return `CREATE VIRTUAL TABLE ` + index + ` USING fts4(
+		media_type, media_id, title, original_title, overview, localized_titles,
+		notindexed=media_type, notindexed=media_id, tokenize=unicode61 "remove_diacritics=2")`, nil
+}
+
+// Search busca no catálogo local por título, título original, títulos
+// localizados e sinopse, ignorando acentos e maiúsculas. Cada palavra da
+// consulta precisa aparecer (como prefixo) no item. Os resultados vêm do
+// mais relevante para o menos relevante, com títulos pesando mais que a
+// sinopse.
+func (d *Database) Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	if err := d.requireSchema(ctx); err != nil {
+		return nil, err
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	var results []SearchResult
+	var err error
+	switch d.dialect {
+	case PostgreSQL:
+		results, err = d.searchPostgres(ctx, terms, opts.MediaType, limit, opts.Offset)
+	case MySQL:
+		results, err = d.searchMySQL(ctx, terms, opts.MediaType, limit, opts.Offset)
+	default:
+		results, err = d.searchSQLite(ctx, terms, opts.MediaType, limit, opts.Offset)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return results, d.loadSearchResults(ctx, results)
+}
+
+func (d *Database) searchSQLite(ctx context.Context, terms []string, mediaType string, limit, offset int) ([]SearchResult, error) {
+	match := make([]string, len(terms))
+	for i, term := range terms {
+		match[i] = term + "*"
+	}
+	index := d.table("search_index")
+	where := index + " MATCH ?"
+	args := []interface{}{strings.Join(match, " ")}
+	if mediaType != "" {
+		where += " AND media_type = ?"
+		args = append(args, mediaType)
+	}
+
+	// O módulo usado é o da criação do índice, não o do driver atual.
+	var ddl string
+	if err := d.db.QueryRowContext(ctx, `SELECT sql FROM sqlite_master WHERE name = ?`, index).Scan(&ddl); err != nil {
+		return nil, err
+	}
+	if strings.Contains(strings.ToLower(ddl), "fts5") {
+		// bm25 devolve valores menores para resultados melhores; os pesos
+		// seguem a ordem das colunas do índice.
+		rows, err := d.db.QueryContext(ctx, `SELECT media_type, media_id, -bm25(`+index+`, 0, 0, 10.0, 5.0, 1.0, 8.0) AS score
+			FROM `+index+` WHERE `+where+` ORDER BY score DESC LIMIT ? OFFSET ?`,
+			append(args, limit, offset)...)
+		if err != nil {
+			return nil, err
+		}
+		return scanSearchResults(rows)
+	}
+
+	// O FTS4 não tem função de ranking embutida. A pontuação de titleScore
+	// é calculada no próprio SQLite, com uma subconsulta por termo nas
+	// colunas de título, para que ordenação e paginação não precisem ler
+	// todos os resultados.
+	score := "1"
+	var scoreArgs []interface{}
+	for _, term := range terms {
+		score += " + 10 * (rowid IN (SELECT rowid FROM " + index + " WHERE " + index + " MATCH ?))"
+		scoreArgs = append(scoreArgs, fmt.Sprintf("title:%[1]s* OR original_title:%[1]s* OR localized_titles:%[1]s*", term))
+	}
+	args = append(scoreArgs, args...)
+	rows, err := d.db.QueryContext(ctx, `SELECT media_type, media_id, `+score+` AS score
+		FROM `+index+` WHERE `+where+` ORDER BY score DESC, rowid LIMIT ? OFFSET ?`,
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSearchResults(rows)
+}
+
+// titleScore pontua um resultado que já atende à consulta: 1, mais 10 para
+// cada termo presente nos títulos. É a regra do índice FTS4 e de
+// MemoryStore.
+func titleScore(terms []string, titles string) float64 {
+	words := searchTerms(titles)
+	score := 1.0
+	for _, term := range terms {
+		if hasPrefixWord(words, term) {
+			score += 10
+		}
+	}
+	return score
+}
+
+func hasPrefixWord(words []string, term string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// rankResults ordena por pontuação e aplica offset e limit.
+func rankResults(results []SearchResult, limit, offset int) []SearchResult {
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if offset >= len(results) {
+		return nil
+	}
+	results = results[offset:]
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (d *Database) searchPostgres(ctx context.Context, terms []string, mediaType string, limit, offset int) ([]SearchResult, error) {
+	tsquery := make([]string, len(terms))
+	for i, term := range terms {
+		tsquery[i] = term + ":*"
+	}
+	where := "document @@ to_tsquery('simple', ?)"
+	args := []interface{}{strings.Join(tsquery, " & ")}
+	if mediaType != "" {
+		where += " AND media_type = ?"
+		args = append(args, mediaType)
+	}
+	query := `SELECT media_type, media_id, ts_rank(document, to_tsquery('simple', ?)) AS score
+		FROM ` + d.table("search_index") + ` WHERE ` + where + ` ORDER BY score DESC LIMIT ? OFFSET ?`
+	args = append([]interface{}{args[0]}, args...)
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSearchResults(rows)
+}
+
+// mysqlMinTokenSize é o valor padrão de innodb_ft_min_token_size: palavras
+// menores não entram no índice FULLTEXT e são procuradas com LIKE.
+const mysqlMinTokenSize = 3
+
+// searchMySQL ordena pela relevância de MATCH ... AGAINST. Termos com menos
+// de mysqlMinTokenSize letras filtram com LIKE, sem afetar a pontuação, e
+// uma consulta só com termos curtos devolve pontuação 1 para todos.
+func (d *Database) searchMySQL(ctx context.Context, terms []string, mediaType string, limit, offset int) ([]SearchResult, error) {
+	var fulltext, where []string
+	var args []interface{}
+	for _, term := range terms {
+		if len([]rune(term)) >= mysqlMinTokenSize {
+			fulltext = append(fulltext, "+"+term+"*")
+			continue
+		}
+		where = append(where, "document LIKE ?")
+		args = append(args, "%"+term+"%")
+	}
+	score := "1"
+	if len(fulltext) > 0 {
+		score = "MATCH(document) AGAINST(? IN BOOLEAN MODE)"
+		against := strings.Join(fulltext, " ")
+		where = append([]string{score}, where...)
+		args = append([]interface{}{against, against}, args...)
+	}
+	if mediaType != "" {
+		where = append(where, "media_type = ?")
+		args = append(args, mediaType)
+	}
+	rows, err := d.db.QueryContext(ctx, `SELECT media_type, media_id, `+score+` AS score FROM `+d.table("search_index")+` WHERE `+
+		strings.Join(where, " AND ")+` ORDER BY score DESC, media_id LIMIT ? OFFSET ?`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, err
+	}
+	return scanSearchResults(rows)
+}
+
+func scanSearchResults(rows *sql.Rows) ([]SearchResult, error) {
+	defer rows.Close()
+	var results []SearchResult
+	for rows.Next() {
+		var r SearchResult
+		if err := rows.Scan(&r.MediaType, &r.ID, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// loadSearchResults preenche Movie/Show com duas consultas, uma por tipo.
+func (d *Database) loadSearchResults(ctx context.Context, results []SearchResult) error {
+	var movieIDs, showIDs []int
+	for _, r := range results {
+		if r.MediaType == models.MediaTypeTV {
+			showIDs = append(showIDs, r.ID)
+		} else {
+			movieIDs = append(movieIDs, r.ID)
+		}
+	}
+	movies, err := d.GetMoviesByIDsContext(ctx, movieIDs)
+	if err != nil {
+		return err
+	}
+	shows, err := d.GetTVShowsByIDsContext(ctx, showIDs)
+	if err != nil {
+		return err
+	}
+	for i := range movies {
+		for j := range results {
+			if results[j].MediaType != models.MediaTypeTV && results[j].ID == movies[i].ID {
+				results[j].Movie = &movies[i]
+			}
+		}
+	}
+	for i := range shows {
+		for j := range results {
+			if results[j].MediaType == models.MediaTypeTV && results[j].ID == shows[i].ID {
+				results[j].Show = &shows[i]
+			}
+		}
+	}
+	return nil
+}
+
+// searchTerms quebra a consulta em palavras minúsculas sem acentos,
+// descartando pontuação e operadores do FTS.
+func searchTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = foldAccents(w)
+	}
+	return words
+}
+
+var accentReplacer = func() *strings.Replacer {
+	from, to := []rune(accentedChars), []rune(unaccentedChars)
+	pairs := make([]string, 0, len(from)*2)
+	for i := range from {
+		pairs = append(pairs, string(from[i]), string(to[i]))
+	}
+	return strings.NewReplacer(pairs...)
+}()
+
+func foldAccents(s string) string {
+	return accentReplacer.Replace(s)
+}
+
+// SaveLocalizedTitles substitui os títulos localizados de um filme ou série
+// e atualiza o índice de busca na mesma transação.
+func (d *Database) SaveLocalizedTitles(mediaType string, mediaID int, translations []models.Translation) error {
+	return d.SaveLocalizedTitlesContext(context.Background(), mediaType, mediaID, translations)
+}
+
+func (d *Database) SaveLocalizedTitlesContext(ctx context.Context, mediaType string, mediaID int, translations []models.Translation) error {
+	return d.WithTx(ctx, func(tx *Tx) error { return tx.SaveLocalizedTitles(mediaType, mediaID, translations) })
+}
+
+func (tx *Tx) SaveLocalizedTitles(mediaType string, mediaID int, translations []models.Translation) error {
+	t := moviesTable
+	if mediaType == models.MediaTypeTV {
+		t = tvShowsTable
+	}
+	dialect := tx.d.dialect
+	if _, err := tx.tx.ExecContext(tx.ctx, dialect.Rebind(`DELETE FROM `+tx.d.table("localized_titles")+` WHERE media_type = ? AND media_id = ?`), mediaType, mediaID); err != nil {
+		return err
+	}
+	var titles []models.Translation
+	for _, tr := range translations {
+		if tr.Title != "" {
+			titles = append(titles, tr)
+		}
+	}
+	query := dialect.Upsert(tx.d.table("localized_titles"), []string{"media_type", "media_id", "language", "title"},
+		[]string{"media_type", "media_id", "language"}, []string{"title"})
+	if err := tx.execEach(query, len(titles), func(i int) []interface{} {
+		return []interface{}{mediaType, mediaID, titles[i].Tag(), titles[i].Title}
+	}); err != nil {
+		return err
+	}
+	_, err := tx.tx.ExecContext(tx.ctx, tx.d.refreshSearchSQL(t, "id = ?"), mediaID)
+	return err
+}
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// PurgeResult conta as linhas apagadas por PurgeDeleted.
+type PurgeResult struct {
+	Movies  int
+	TVShows int
+}
+
+// SoftDeleteMovies marca filmes como removidos do TMDB com o status
+// informado (models.StatusNotFound, models.StatusMissing...). As linhas
+// continuam no banco, mas saem das listagens e da busca; GetMovie ainda as
+// devolve. Filmes já removidos ficam como estão. Salvar o filme de novo o
+// reativa. Devolve quantos filmes foram marcados.
+func (d *Database) SoftDeleteMovies(ids []int, status string) (int, error) {
+	return d.SoftDeleteMoviesContext(context.Background(), ids, status)
+}
+
+func (d *Database) SoftDeleteMoviesContext(ctx context.Context, ids []int, status string) (int, error) {
+	var n int
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		n, err = tx.SoftDeleteMovies(ids, status)
+		return err
+	})
+	return n, err
+}
+
+// SoftDeleteTVShows segue as regras de SoftDeleteMovies.
+func (d *Database) SoftDeleteTVShows(ids []int, status string) (int, error) {
+	return d.SoftDeleteTVShowsContext(context.Background(), ids, status)
+}
+
+func (d *Database) SoftDeleteTVShowsContext(ctx context.Context, ids []int, status string) (int, error) {
+	var n int
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		n, err = tx.SoftDeleteTVShows(ids, status)
+		return err
+	})
+	return n, err
+}
+
+// MarkMissingMovies marca com models.StatusMissing os filmes ativos que não
+// estão em seen. Deve ser chamado apenas depois de uma coleta completa, com
+// todos os IDs que ela devolveu.
+func (d *Database) MarkMissingMovies(seen []int) (int, error) {
+	return d.MarkMissingMoviesContext(context.Background(), seen)
+}
+
+func (d *Database) MarkMissingMoviesContext(ctx context.Context, seen []int) (int, error) {
+	var n int
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		n, err = tx.MarkMissingMovies(seen)
+		return err
+	})
+	return n, err
+}
+
+// MarkMissingTVShows segue as regras de MarkMissingMovies.
+func (d *Database) MarkMissingTVShows(seen []int) (int, error) {
+	return d.MarkMissingTVShowsContext(context.Background(), seen)
+}
+
+func (d *Database) MarkMissingTVShowsContext(ctx context.Context, seen []int) (int, error) {
+	var n int
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		n, err = tx.MarkMissingTVShows(seen)
+		return err
+	})
+	return n, err
+}
+
+// PurgeDeleted apaga de vez os filmes e séries removidos há mais de
+// olderThan, junto com seus gêneros, vídeos, títulos localizados e
+// métricas. O histórico de mudanças é mantido.
+func (d *Database) PurgeDeleted(olderThan time.Duration) (PurgeResult, error) {
+	return d.PurgeDeletedContext(context.Background(), olderThan)
+}
+
+func (d *Database) PurgeDeletedContext(ctx context.Context, olderThan time.Duration) (PurgeResult, error) {
+	var result PurgeResult
+	err := d.WithTx(ctx, func(tx *Tx) error {
+		var err error
+		result, err = tx.PurgeDeleted(olderThan)
+		return err
+	})
+	return result, err
+}
+
+func (tx *Tx) SoftDeleteMovies(ids []int, status string) (int, error) {
+	return tx.softDelete(moviesTable, ids, status)
+}
+
+func (tx *Tx) SoftDeleteTVShows(ids []int, status string) (int, error) {
+	return tx.softDelete(tvShowsTable, ids, status)
+}
+
+func (tx *Tx) MarkMissingMovies(seen []int) (int, error) {
+	return tx.markMissing(moviesTable, seen)
+}
+
+func (tx *Tx) MarkMissingTVShows(seen []int) (int, error) {
+	return tx.markMissing(tvShowsTable, seen)
+}
+
+func (tx *Tx) PurgeDeleted(olderThan time.Duration) (PurgeResult, error) {
+	cutoff := time.Now().UTC().Add(-olderThan)
+	var result PurgeResult
+	var err error
+	if result.Movies, err = tx.purge(moviesTable, cutoff); err != nil {
+		return result, err
+	}
+	result.TVShows, err = tx.purge(tvShowsTable, cutoff)
+	return result, err
+}
+
+func (tx *Tx) softDelete(t mediaTable, ids []int, status string) (int, error) {
+	if status == "" || status == models.StatusActive {
+		return 0, fmt.Errorf("status de remoção inválido: %q", status)
+	}
+	table, index := tx.d.table(t.table), tx.d.table("search_index")
+	now := time.Now().UTC()
+	total := 0
+	for _, chunk := range chunkIDs(ids) {
+		args := []interface{}{status, now}
+		for _, id := range chunk {
+			args = append(args, id)
+		}
+		res, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Rebind(`UPDATE `+table+` SET status = ?, deleted_at = ?
+			WHERE id IN (`+placeholders(len(chunk))+`) AND deleted_at IS NULL`), args...)
+		if err != nil {
+			return total, err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += int(n)
+		if _, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Rebind(`DELETE FROM `+index+` WHERE media_type = ?
+			AND media_id IN (`+placeholders(len(chunk))+`)`), append([]interface{}{t.mediaType}, args[2:]...)...); err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+func (tx *Tx) markMissing(t mediaTable, seen []int) (int, error) {
+	rows, err := tx.tx.QueryContext(tx.ctx, `SELECT id FROM `+tx.d.table(t.table)+` WHERE deleted_at IS NULL`)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	found := make(map[int]bool, len(seen))
+	for _, id := range seen {
+		found[id] = true
+	}
+	var missing []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return 0, err
+		}
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	rows.Close()
+	return tx.softDelete(t, missing, models.StatusMissing)
+}
+
+// purge apaga os itens de t removidos antes de cutoff e as linhas que
+// dependem deles.
+func (tx *Tx) purge(t mediaTable, cutoff time.Time) (int, error) {
+	table := tx.d.table(t.table)
+	expired := `SELECT id FROM ` + table + ` WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+	// As dependentes são apagadas primeiro, enquanto a subconsulta ainda
+	// encontra os itens.
+	dependents := []struct {
+		table string
+		where string
+		args  []interface{}
+	}{
+		{t.genreTable, t.genreFK + ` IN (` + expired + `)`, []interface{}{cutoff}},
+		{"videos", `media_type = ? AND media_id IN (` + expired + `)`, []interface{}{t.mediaType, cutoff}},
+		{"localized_titles", `media_type = ? AND media_id IN (` + expired + `)`, []interface{}{t.mediaType, cutoff}},
+		{"search_index", `media_type = ? AND media_id IN (` + expired + `)`, []interface{}{t.mediaType, cutoff}},
+		{t.metrics, `id IN (` + expired + `)`, []interface{}{cutoff}},
+	}
+	for _, dep := range dependents {
+		if _, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Rebind(`DELETE FROM `+tx.d.table(dep.table)+` WHERE `+dep.where), dep.args...); err != nil {
+			return 0, fmt.Errorf("erro ao apagar %s: %v", dep.table, err)
+		}
+	}
+	res, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Rebind(`DELETE FROM `+table+` WHERE deleted_at IS NOT NULL AND deleted_at < ?`), cutoff)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// Store reúne as gravações e leituras do catálogo. É implementada por
+// Database e por MemoryStore, que permite testar código que depende do
+// catálogo sem um banco de dados.
+//
+// Os métodos seguem as variantes ...Context de Database; as regras de cada
+// um (GenreIDs nil, ErrNotFound, paginação) valem para as duas
+// implementações.
+type Store interface {
+	SaveMovieContext(ctx context.Context, movie *models.Movie) error
+	SaveTVShowContext(ctx context.Context, show *models.TVShow) error
+	SaveMoviesBulkContext(ctx context.Context, movies []models.Movie) error
+	SaveTVShowsBulkContext(ctx context.Context, shows []models.TVShow) error
+	SaveMoviesBulkWithResultContext(ctx context.Context, movies []models.Movie) (SaveResult, error)
+	SaveTVShowsBulkWithResultContext(ctx context.Context, shows []models.TVShow) (SaveResult, error)
+	SaveGenresContext(ctx context.Context, genres []models.Genre) error
+	SaveMovieGenresContext(ctx context.Context, movieID int, genreIDs []int) error
+	SaveTVShowGenresContext(ctx context.Context, tvShowID int, genreIDs []int) error
+	SaveMovieGenresBulkContext(ctx context.Context, relations []models.MovieGenre) error
+	SaveTVShowGenresBulkContext(ctx context.Context, relations []models.TVShowGenre) error
+	SaveVideosContext(ctx context.Context, mediaType string, mediaID int, videos []models.Video) error
+	SaveCountriesContext(ctx context.Context, countries []models.Country) error
+	SaveLanguagesContext(ctx context.Context, languages []models.Language) error
+	SaveLocalizedTitlesContext(ctx context.Context, mediaType string, mediaID int, translations []models.Translation) error
+	SaveMovieMetricsContext(ctx context.Context, date time.Time, movies []models.Movie) error
+	SaveTVShowMetricsContext(ctx context.Context, date time.Time, shows []models.TVShow) error
+
+	MovieIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error)
+	TVShowIDsWithoutTrailerContext(ctx context.Context, afterID, limit int) ([]int, error)
+	UpdateMovieTrailerContext(ctx context.Context, movieID int, trailerURL string) error
+	UpdateTVShowTrailerContext(ctx context.Context, showID int, trailerURL string) error
+	SoftDeleteMoviesContext(ctx context.Context, ids []int, status string) (int, error)
+	SoftDeleteTVShowsContext(ctx context.Context, ids []int, status string) (int, error)
+	MarkMissingMoviesContext(ctx context.Context, seen []int) (int, error)
+	MarkMissingTVShowsContext(ctx context.Context, seen []int) (int, error)
+	PurgeDeletedContext(ctx context.Context, olderThan time.Duration) (PurgeResult, error)
+
+	ImagePathsContext(ctx context.Context) ([]models.ImageRef, error)
+	GetLocalImageContext(ctx context.Context, path, size string) (*models.LocalImage, error)
+	SaveLocalImageContext(ctx context.Context, img *models.LocalImage) error
+
+	GetMovieContext(ctx context.Context, id int) (*models.Movie, error)
+	GetMoviesByIDsContext(ctx context.Context, ids []int) ([]models.Movie, error)
+	ListMoviesContext(ctx context.Context, filter ListFilter) (*MoviePage, error)
+	GetTVShowContext(ctx context.Context, id int) (*models.TVShow, error)
+	GetTVShowsByIDsContext(ctx context.Context, ids []int) ([]models.TVShow, error)
+	ListTVShowsContext(ctx context.Context, filter ListFilter) (*TVShowPage, error)
+	ListGenresContext(ctx context.Context) ([]models.Genre, error)
+	ListGenresForContext(ctx context.Context, mediaType, language string) ([]models.Genre, error)
+	GetGenreContext(ctx context.Context, mediaType, language string, id int) (*models.Genre, error)
+	Search(ctx context.Context, query string, opts SearchOptions) ([]SearchResult, error)
+	MovieMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error)
+	TVShowMetricsContext(ctx context.Context, id int, since, until time.Time) ([]models.MediaMetric, error)
+	MovieMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error)
+	TVShowMoversContext(ctx context.Context, opts MoversOptions) ([]Mover, error)
+	ChangesSinceContext(ctx context.Context, since time.Time) ([]models.MediaChange, error)
+
+	// InTx executa fn em uma transação: as gravações feitas por tx só
+	// valem se fn não devolver erro. Ver Database.WithTx.
+	InTx(ctx context.Context, fn func(tx TxStore) error) error
+}
+
+// TxStore reúne as gravações disponíveis dentro de Store.InTx, com os
+// nomes e as regras dos métodos de Tx. Os métodos usam o contexto
+// informado a InTx.
+type TxStore interface {
+	SaveMovie(movie *models.Movie) error
+	SaveTVShow(show *models.TVShow) error
+	SaveMoviesBulk(movies []models.Movie) error
+	SaveTVShowsBulk(shows []models.TVShow) error
+	SaveMoviesBulkWithResult(movies []models.Movie) (SaveResult, error)
+	SaveTVShowsBulkWithResult(shows []models.TVShow) (SaveResult, error)
+	SaveGenres(genres []models.Genre) error
+	SaveMovieGenres(movieID int, genreIDs []int) error
+	SaveTVShowGenres(tvShowID int, genreIDs []int) error
+	SaveMovieGenresBulk(relations []models.MovieGenre) error
+	SaveTVShowGenresBulk(relations []models.TVShowGenre) error
+	SaveVideos(mediaType string, mediaID int, videos []models.Video) error
+	SaveCountries(countries []models.Country) error
+	SaveLanguages(languages []models.Language) error
+	SaveLocalizedTitles(mediaType string, mediaID int, translations []models.Translation) error
+	SaveMovieMetrics(date time.Time, movies []models.Movie) error
+	SaveTVShowMetrics(date time.Time, shows []models.TVShow) error
+	SaveLocalImage(img *models.LocalImage) error
+	UpdateMovieTrailer(movieID int, trailerURL string) error
+	UpdateTVShowTrailer(showID int, trailerURL string) error
+	SoftDeleteMovies(ids []int, status string) (int, error)
+	SoftDeleteTVShows(ids []int, status string) (int, error)
+	MarkMissingMovies(seen []int) (int, error)
+	MarkMissingTVShows(seen []int) (int, error)
+	PurgeDeleted(olderThan time.Duration) (PurgeResult, error)
+}
+
+var (
+	_ Store   = (*Database)(nil)
+	_ Store   = (*MemoryStore)(nil)
+	_ TxStore = (*Tx)(nil)
+	_ TxStore = (*memoryTx)(nil)
+)
@@ -0,0 +1,590 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// forEachStore executa fn contra as duas implementações de Store, com o
+// modo de auditoria ligado, para garantir que seguem as mesmas regras.
+func forEachStore(t *testing.T, fn func(t *testing.T, s Store)) {
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := NewDatabase(filepath.Join(t.TempDir(), "store.db"), WithAutoMigrate(), WithAudit())
+		if err != nil {
+			t.Fatalf("NewDatabase: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		fn(t, db)
+	})
+	t.Run("memory", func(t *testing.T) {
+		s := NewMemoryStore()
+		s.Audit = true
+		fn(t, s)
+	})
+}
+
+func testMovie(id int, title string, popularity float64) models.Movie {
+	return models.Movie{ID: id, Title: title, OriginalTitle: title, Overview: "Sinopse de " + title,
+		ReleaseDate: "2020-01-01", VoteAverage: 7, Popularity: popularity}
+}
+
+func mustSaveMovies(t *testing.T, s Store, movies ...models.Movie) SaveResult {
+	t.Helper()
+	result, err := s.SaveMoviesBulkWithResultContext(context.Background(), movies)
+	if err != nil {
+		t.Fatalf("SaveMoviesBulkWithResultContext: %v", err)
+	}
+	return result
+}
+
+func TestStoreSaveAndGet(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		movie := testMovie(1, "Matrix", 50)
+		movie.GenreIDs = []int{28, 12}
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Inserted: 1}) {
+			t.Errorf("primeira gravação = %+v, esperado 1 inserido", got)
+		}
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Unchanged: 1}) {
+			t.Errorf("gravação repetida = %+v, esperado 1 inalterado", got)
+		}
+		movie.Title = "The Matrix"
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Updated: 1}) {
+			t.Errorf("gravação alterada = %+v, esperado 1 atualizado", got)
+		}
+
+		stored, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if stored.Title != "The Matrix" || !reflect.DeepEqual(stored.GenreIDs, []int{12, 28}) {
+			t.Errorf("filme lido = %q %v", stored.Title, stored.GenreIDs)
+		}
+		if stored.Status != models.StatusActive {
+			t.Errorf("Status = %q, esperado %q", stored.Status, models.StatusActive)
+		}
+		if _, err := s.GetMovieContext(ctx, 2); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetMovieContext de um ID inexistente = %v, esperado ErrNotFound", err)
+		}
+	})
+}
+
+// TestStoreGetByManyIDs pede mais IDs do que os parâmetros aceitos pelo
+// SQLite num único comando, o que exige consultas em lotes.
+func TestStoreGetByManyIDs(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		mustSaveMovies(t, s, testMovie(7, "Sete", 1), testMovie(600, "Seiscentos", 1), testMovie(40000, "Quarenta mil", 1))
+		shows := []models.TVShow{{ID: 7, Name: "Sete"}, {ID: 40000, Name: "Quarenta mil"}}
+		if err := s.SaveTVShowsBulkContext(ctx, shows); err != nil {
+			t.Fatalf("SaveTVShowsBulkContext: %v", err)
+		}
+		ids := make([]int, 0, 40000)
+		for id := 40000; id > 0; id-- {
+			ids = append(ids, id)
+		}
+
+		movies, err := s.GetMoviesByIDsContext(ctx, ids)
+		if err != nil {
+			t.Fatalf("GetMoviesByIDsContext: %v", err)
+		}
+		var got []int
+		for _, m := range movies {
+			got = append(got, m.ID)
+		}
+		if want := []int{40000, 600, 7}; !reflect.DeepEqual(got, want) {
+			t.Errorf("filmes = %v, esperado %v", got, want)
+		}
+
+		tvShows, err := s.GetTVShowsByIDsContext(ctx, ids)
+		if err != nil {
+			t.Fatalf("GetTVShowsByIDsContext: %v", err)
+		}
+		got = nil
+		for _, show := range tvShows {
+			got = append(got, show.ID)
+		}
+		if want := []int{40000, 7}; !reflect.DeepEqual(got, want) {
+			t.Errorf("séries = %v, esperado %v", got, want)
+		}
+	})
+}
+
+func TestStoreSaveTimestamps(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		movie := testMovie(1, "Matrix", 50)
+		movie.GenreIDs = []int{28}
+		if err := s.SaveMovieContext(ctx, &movie); err != nil {
+			t.Fatalf("SaveMovieContext: %v", err)
+		}
+		created, updated := movie.CreatedAt, movie.UpdatedAt
+
+		// Uma atualização de conteúdo mantém CreatedAt da inserção.
+		time.Sleep(5 * time.Millisecond)
+		movie.Title = "The Matrix"
+		if err := s.SaveMovieContext(ctx, &movie); err != nil {
+			t.Fatalf("SaveMovieContext: %v", err)
+		}
+		if !movie.CreatedAt.Equal(created) {
+			t.Errorf("CreatedAt depois da atualização = %v, esperado %v", movie.CreatedAt, created)
+		}
+		if !movie.UpdatedAt.After(updated) {
+			t.Errorf("UpdatedAt não avançou na atualização: %v", movie.UpdatedAt)
+		}
+		updated = movie.UpdatedAt
+
+		// Só os gêneros mudaram: o item conta como atualizado e
+		// UpdatedAt avança.
+		time.Sleep(5 * time.Millisecond)
+		movie.GenreIDs = []int{28, 12}
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Updated: 1}) {
+			t.Errorf("gravação com novos gêneros = %+v, esperado 1 atualizado", got)
+		}
+		stored, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if !stored.CreatedAt.Equal(created) {
+			t.Errorf("CreatedAt lido = %v, esperado %v", stored.CreatedAt, created)
+		}
+		if !stored.UpdatedAt.After(updated) {
+			t.Errorf("UpdatedAt lido = %v, esperado depois de %v", stored.UpdatedAt, updated)
+		}
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Unchanged: 1}) {
+			t.Errorf("gravação repetida = %+v, esperado 1 inalterado", got)
+		}
+	})
+}
+
+func TestStorePopularityOnly(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		movie := testMovie(1, "Matrix", 50)
+		mustSaveMovies(t, s, movie)
+		stored, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+
+		// Só a popularidade mudou: o filme não é reescrito.
+		movie.Popularity = 80
+		if got := mustSaveMovies(t, s, movie); got != (SaveResult{Unchanged: 1}) {
+			t.Errorf("gravação com nova popularidade = %+v, esperado 1 inalterado", got)
+		}
+		// O retrato do dia grava a popularidade atual sem tocar updated_at.
+		if err := s.SaveMovieMetricsContext(ctx, time.Now(), []models.Movie{movie}); err != nil {
+			t.Fatalf("SaveMovieMetricsContext: %v", err)
+		}
+		got, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if got.Popularity != 80 {
+			t.Errorf("Popularity = %v, esperado 80", got.Popularity)
+		}
+		if !got.UpdatedAt.Equal(stored.UpdatedAt) {
+			t.Errorf("UpdatedAt = %v, esperado %v", got.UpdatedAt, stored.UpdatedAt)
+		}
+	})
+}
+
+func TestStoreGenresWithoutMediaType(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		if err := s.SaveGenresContext(ctx, []models.Genre{{ID: 28, Name: "Ação", Language: "pt-BR"}}); err != nil {
+			t.Fatalf("SaveGenresContext: %v", err)
+		}
+		genre, err := s.GetGenreContext(ctx, models.MediaTypeMovie, "pt-BR", 28)
+		if err != nil {
+			t.Fatalf("GetGenreContext: %v", err)
+		}
+		if genre.Name != "Ação" || genre.MediaType != models.MediaTypeMovie {
+			t.Errorf("gênero = %+v, esperado Ação de filmes", genre)
+		}
+	})
+}
+
+func TestStoreListPagination(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		for id := 1; id <= 5; id++ {
+			mustSaveMovies(t, s, testMovie(id, "Filme", float64(id*10)))
+		}
+		var ids []int
+		filter := ListFilter{Limit: 2}
+		for {
+			page, err := s.ListMoviesContext(ctx, filter)
+			if err != nil {
+				t.Fatalf("ListMoviesContext: %v", err)
+			}
+			for _, m := range page.Movies {
+				ids = append(ids, m.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			filter.Cursor = page.NextCursor
+		}
+		if want := []int{5, 4, 3, 2, 1}; !reflect.DeepEqual(ids, want) {
+			t.Errorf("IDs paginados = %v, esperado %v", ids, want)
+		}
+	})
+}
+
+func TestStoreSoftDeleteAndPurge(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		mustSaveMovies(t, s, testMovie(1, "Um", 1), testMovie(2, "Dois", 2), testMovie(3, "Três", 3))
+
+		n, err := s.SoftDeleteMoviesContext(ctx, []int{1}, models.StatusNotFound)
+		if err != nil || n != 1 {
+			t.Fatalf("SoftDeleteMoviesContext = %d, %v", n, err)
+		}
+		n, err = s.MarkMissingMoviesContext(ctx, []int{3})
+		if err != nil || n != 1 {
+			t.Fatalf("MarkMissingMoviesContext = %d, %v", n, err)
+		}
+		page, err := s.ListMoviesContext(ctx, ListFilter{})
+		if err != nil {
+			t.Fatalf("ListMoviesContext: %v", err)
+		}
+		if len(page.Movies) != 1 || page.Movies[0].ID != 3 {
+			t.Errorf("ativos = %+v, esperado só o filme 3", page.Movies)
+		}
+		missing, err := s.GetMovieContext(ctx, 2)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if missing.Status != models.StatusMissing || missing.DeletedAt == nil {
+			t.Errorf("filme 2: Status = %q, DeletedAt = %v", missing.Status, missing.DeletedAt)
+		}
+
+		purged, err := s.PurgeDeletedContext(ctx, -time.Minute)
+		if err != nil {
+			t.Fatalf("PurgeDeletedContext: %v", err)
+		}
+		if purged.Movies != 2 {
+			t.Errorf("filmes apagados = %d, esperado 2", purged.Movies)
+		}
+		if _, err := s.GetMovieContext(ctx, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("filme apagado ainda encontrado: %v", err)
+		}
+	})
+}
+
+// TestStorePurgeCascade confere que PurgeDeleted apaga o que depende do
+// item: um item salvo de novo com o mesmo ID não herda gêneros, títulos
+// localizados, métricas nem a consulta de trailer do item apagado.
+func TestStorePurgeCascade(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		day := time.Now().UTC()
+		movie := testMovie(1, "Matrix", 1)
+		movie.GenreIDs = []int{28}
+		mustSaveMovies(t, s, movie)
+		if err := s.SaveLocalizedTitlesContext(ctx, models.MediaTypeMovie, 1,
+			[]models.Translation{{Language: "pt", Country: "BR", Title: "Vingança Secreta"}}); err != nil {
+			t.Fatalf("SaveLocalizedTitlesContext: %v", err)
+		}
+		if err := s.SaveMovieMetricsContext(ctx, day, []models.Movie{movie}); err != nil {
+			t.Fatalf("SaveMovieMetricsContext: %v", err)
+		}
+		if err := s.UpdateMovieTrailerContext(ctx, 1, ""); err != nil {
+			t.Fatalf("UpdateMovieTrailerContext: %v", err)
+		}
+
+		if _, err := s.SoftDeleteMoviesContext(ctx, []int{1}, models.StatusNotFound); err != nil {
+			t.Fatalf("SoftDeleteMoviesContext: %v", err)
+		}
+		if purged, err := s.PurgeDeletedContext(ctx, -time.Minute); err != nil || purged.Movies != 1 {
+			t.Fatalf("PurgeDeletedContext = %+v, %v", purged, err)
+		}
+		// GenreIDs nil mantém os vínculos existentes, que não devem mais
+		// existir.
+		mustSaveMovies(t, s, testMovie(1, "Outro filme", 1))
+
+		stored, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if len(stored.GenreIDs) != 0 {
+			t.Errorf("GenreIDs = %v, esperado nenhum", stored.GenreIDs)
+		}
+		metrics, err := s.MovieMetricsContext(ctx, 1, day, day)
+		if err != nil || len(metrics) != 0 {
+			t.Errorf("métricas = %+v, %v, esperado nenhuma", metrics, err)
+		}
+		results, err := s.Search(ctx, "Vingança", SearchOptions{})
+		if err != nil || len(results) != 0 {
+			t.Errorf("busca pelo título localizado = %+v, %v, esperado nada", results, err)
+		}
+		ids, err := s.MovieIDsWithoutTrailerContext(ctx, 0, 10)
+		if err != nil || !reflect.DeepEqual(ids, []int{1}) {
+			t.Errorf("sem trailer = %v, %v, esperado [1]", ids, err)
+		}
+	})
+}
+
+// TestStoreLocalizedTitles confere que cada idioma guarda um único título:
+// o último informado.
+func TestStoreLocalizedTitles(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		mustSaveMovies(t, s, testMovie(1, "Matrix", 1))
+		err := s.SaveLocalizedTitlesContext(ctx, models.MediaTypeMovie, 1, []models.Translation{
+			{Language: "pt", Country: "BR", Title: "Primeiro"},
+			{Language: "es", Country: "ES", Title: ""},
+			{Language: "pt", Country: "BR", Title: "Segundo"},
+			{Language: "pt", Country: "PT", Title: "Lusitano"},
+		})
+		if err != nil {
+			t.Fatalf("SaveLocalizedTitlesContext: %v", err)
+		}
+		for query, want := range map[string]int{"Primeiro": 0, "Segundo": 1, "Lusitano": 1} {
+			results, err := s.Search(ctx, query, SearchOptions{})
+			if err != nil {
+				t.Fatalf("Search(%q): %v", query, err)
+			}
+			if len(results) != want {
+				t.Errorf("Search(%q) = %+v, esperado %d resultado(s)", query, results, want)
+			}
+		}
+	})
+}
+
+func TestStoreTrailers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		mustSaveMovies(t, s, testMovie(1, "Um", 1), testMovie(2, "Dois", 2))
+
+		ids, err := s.MovieIDsWithoutTrailerContext(ctx, 0, 10)
+		if err != nil {
+			t.Fatalf("MovieIDsWithoutTrailerContext: %v", err)
+		}
+		if !reflect.DeepEqual(ids, []int{1, 2}) {
+			t.Errorf("sem trailer = %v, esperado [1 2]", ids)
+		}
+		// Um trailer vazio marca o filme como consultado.
+		if err := s.UpdateMovieTrailerContext(ctx, 1, ""); err != nil {
+			t.Fatalf("UpdateMovieTrailerContext: %v", err)
+		}
+		if err := s.UpdateMovieTrailerContext(ctx, 2, "https://www.youtube.com/watch?v=abc"); err != nil {
+			t.Fatalf("UpdateMovieTrailerContext: %v", err)
+		}
+		if ids, err = s.MovieIDsWithoutTrailerContext(ctx, 0, 10); err != nil || len(ids) != 0 {
+			t.Errorf("sem trailer depois da consulta = %v, %v", ids, err)
+		}
+		movie, err := s.GetMovieContext(ctx, 2)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if movie.TrailerURL != "https://www.youtube.com/watch?v=abc" {
+			t.Errorf("TrailerURL = %q", movie.TrailerURL)
+		}
+	})
+}
+
+func TestStoreSearch(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		mustSaveMovies(t, s, testMovie(1, "Matrix", 1), testMovie(2, "Matrix Reloaded", 2), testMovie(3, "Amélie", 3))
+
+		results, err := s.Search(ctx, "matrix", SearchOptions{})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		found := map[int]bool{}
+		for _, r := range results {
+			if r.Movie == nil || r.Movie.ID != r.ID {
+				t.Errorf("resultado sem filme: %+v", r)
+			}
+			found[r.ID] = true
+		}
+		if len(results) != 2 || !found[1] || !found[2] {
+			t.Errorf("resultados = %+v, esperado os filmes 1 e 2", results)
+		}
+	})
+}
+
+func TestStoreInTx(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		errRollback := errors.New("desfazer")
+		err := s.InTx(ctx, func(tx TxStore) error {
+			movie := testMovie(1, "Um", 1)
+			if err := tx.SaveMovie(&movie); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("InTx = %v, esperado o erro de fn", err)
+		}
+		if _, err := s.GetMovieContext(ctx, 1); !errors.Is(err, ErrNotFound) {
+			t.Errorf("gravação desfeita ainda visível: %v", err)
+		}
+
+		err = s.InTx(ctx, func(tx TxStore) error {
+			if err := tx.SaveMoviesBulk([]models.Movie{testMovie(1, "Um", 1)}); err != nil {
+				return err
+			}
+			_, err := tx.SoftDeleteMovies([]int{1}, models.StatusNotFound)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("InTx: %v", err)
+		}
+		movie, err := s.GetMovieContext(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetMovieContext: %v", err)
+		}
+		if movie.Status != models.StatusNotFound {
+			t.Errorf("Status = %q, esperado %q", movie.Status, models.StatusNotFound)
+		}
+	})
+}
+
+func TestStoreChangesSince(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := WithSyncJob(context.Background(), "diario")
+		since := time.Now().Add(-time.Second)
+		movie := testMovie(1, "Matrix", 1)
+		mustSaveMovies(t, s, movie)
+		// A popularidade muda a cada sincronização e não é auditada.
+		movie.Title = "The Matrix"
+		movie.Popularity = 99
+		if err := s.SaveMoviesBulkContext(ctx, []models.Movie{movie}); err != nil {
+			t.Fatalf("SaveMoviesBulkWithResultContext: %v", err)
+		}
+		if err := s.UpdateMovieTrailerContext(ctx, 1, "https://www.youtube.com/watch?v=abc"); err != nil {
+			t.Fatalf("UpdateMovieTrailerContext: %v", err)
+		}
+
+		changes, err := s.ChangesSinceContext(context.Background(), since)
+		if err != nil {
+			t.Fatalf("ChangesSinceContext: %v", err)
+		}
+		type change struct{ field, old, new, job string }
+		var got []change
+		for _, c := range changes {
+			if c.MediaType != models.MediaTypeMovie || c.MediaID != 1 {
+				t.Errorf("mudança de outro item: %+v", c)
+			}
+			got = append(got, change{c.Field, c.OldValue, c.NewValue, c.SyncJob})
+		}
+		want := []change{
+			{"title", "Matrix", "The Matrix", "diario"},
+			{"trailer_url", "", "https://www.youtube.com/watch?v=abc", "diario"},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("mudanças = %+v, esperado %+v", got, want)
+		}
+	})
+}
+
+// TestStoreMetricsSameDay confere que um segundo retrato no mesmo dia, em
+// UTC, substitui o primeiro.
+func TestStoreMetricsSameDay(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		morning := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+		// 01:00 do dia 2 em UTC+3 ainda é dia 1 em UTC.
+		night := time.Date(2024, 1, 2, 1, 0, 0, 0, time.FixedZone("UTC+3", 3*60*60))
+		movie := testMovie(1, "Matrix", 10)
+		movie.VoteCount = 100
+		if err := s.SaveMovieMetricsContext(ctx, morning, []models.Movie{movie}); err != nil {
+			t.Fatalf("SaveMovieMetricsContext: %v", err)
+		}
+		movie.Popularity, movie.VoteCount = 20, 120
+		if err := s.SaveMovieMetricsContext(ctx, night, []models.Movie{movie}); err != nil {
+			t.Fatalf("SaveMovieMetricsContext: %v", err)
+		}
+
+		got, err := s.MovieMetricsContext(ctx, 1, morning, night)
+		if err != nil {
+			t.Fatalf("MovieMetricsContext: %v", err)
+		}
+		want := []models.MediaMetric{{ID: 1, Date: "2024-01-01", Popularity: 20, VoteAverage: 7, VoteCount: 120}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("retratos = %+v, esperado %+v", got, want)
+		}
+	})
+}
+
+func TestStoreMovers(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s Store) {
+		ctx := context.Background()
+		day := func(d int) time.Time { return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC) }
+		snapshot := func(date time.Time, popularity map[int]float64) {
+			var movies []models.Movie
+			for id, p := range popularity {
+				m := testMovie(id, "Filme", p)
+				m.VoteCount = int(p) * 10
+				movies = append(movies, m)
+			}
+			if err := s.SaveMovieMetricsContext(ctx, date, movies); err != nil {
+				t.Fatalf("SaveMovieMetricsContext: %v", err)
+			}
+		}
+		// O filme 4 só tem um retrato na janela e o 5 tem o primeiro fora
+		// dela; os dois ficam de fora.
+		snapshot(day(1), map[int]float64{5: 1})
+		snapshot(day(10), map[int]float64{1: 10, 2: 50, 3: 30})
+		snapshot(day(12), map[int]float64{1: 25, 2: 45})
+		snapshot(day(14), map[int]float64{1: 40, 2: 20, 3: 35, 4: 90, 5: 80})
+
+		tests := []struct {
+			name    string
+			opts    MoversOptions
+			ids     []int
+			changes []float64
+		}{
+			{"altas", MoversOptions{}, []int{1, 3, 2}, []float64{30, 5, -30}},
+			{"quedas", MoversOptions{Falling: true}, []int{2, 3, 1}, []float64{-30, 5, 30}},
+			{"limite", MoversOptions{Limit: 2}, []int{1, 3}, []float64{30, 5}},
+			{"votos", MoversOptions{Field: "vote_count"}, []int{1, 3, 2}, []float64{300, 50, -300}},
+			{"janela menor", MoversOptions{Until: day(12)}, []int{1, 2}, []float64{15, -5}},
+		}
+		for _, tt := range tests {
+			opts := tt.opts
+			opts.Since = day(10)
+			if opts.Until.IsZero() {
+				opts.Until = day(14)
+			}
+			movers, err := s.MovieMoversContext(ctx, opts)
+			if err != nil {
+				t.Fatalf("%s: MovieMoversContext: %v", tt.name, err)
+			}
+			var ids []int
+			var changes []float64
+			for _, m := range movers {
+				ids = append(ids, m.ID)
+				changes = append(changes, m.Change)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) || !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("%s: movers = %v %v, esperado %v %v", tt.name, ids, changes, tt.ids, tt.changes)
+			}
+		}
+
+		movers, err := s.MovieMoversContext(ctx, MoversOptions{Since: day(10), Until: day(14), Limit: 1})
+		if err != nil {
+			t.Fatalf("MovieMoversContext: %v", err)
+		}
+		want := Mover{ID: 1, Change: 30,
+			From: models.MediaMetric{ID: 1, Date: "2024-01-10", Popularity: 10, VoteAverage: 7, VoteCount: 100},
+			To:   models.MediaMetric{ID: 1, Date: "2024-01-14", Popularity: 40, VoteAverage: 7, VoteCount: 400}}
+		if len(movers) != 1 || movers[0] != want {
+			t.Errorf("movers = %+v, esperado %+v", movers, want)
+		}
+		if _, err := s.MovieMoversContext(ctx, MoversOptions{Field: "titulo"}); err == nil {
+			t.Error("MovieMoversContext aceitou uma métrica inválida")
+		}
+	})
+}
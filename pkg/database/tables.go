@@ -0,0 +1,116 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// TableNames lista os nomes lógicos das tabelas da biblioteca, aceitos como
+// chaves por WithTableNames.
+var TableNames = []string{
+	"movies", "tv_shows", "genres", "movie_genres", "tvshow_genres", "videos",
+	"countries", "languages", "images", "localized_titles", "search_index", "media_changes",
+	"movie_metrics", "tvshow_metrics", "schema_migrations", "schema_migration_steps",
+}
+
+var (
+	identifierPattern  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	tablePlaceholder   = regexp.MustCompile(`\{\{(\w+)\}\}`)
+	defaultTableLookup = func() map[string]bool {
+		m := make(map[string]bool, len(TableNames))
+		for _, name := range TableNames {
+			m[name] = true
+		}
+		return m
+	}()
+)
+
+// WithTablePrefix acrescenta prefix ao nome de todas as tabelas (e índices)
+// da biblioteca, inclusive schema_migrations, permitindo vários catálogos
+// no mesmo schema. Nomes definidos por WithTableNames não recebem o prefixo.
+func WithTablePrefix(prefix string) Option {
+	return func(o *options) {
+		if prefix != "" && !identifierPattern.MatchString(prefix) {
+			o.err = fmt.Errorf("prefixo de tabela inválido: %q", prefix)
+			return
+		}
+		o.tablePrefix = prefix
+	}
+}
+
+// WithTableNames troca o nome de tabelas específicas, com o nome lógico
+// (ver TableNames) como chave.
+func WithTableNames(names map[string]string) Option {
+	return func(o *options) {
+		if o.tableNames == nil {
+			o.tableNames = make(map[string]string, len(names))
+		}
+		for logical, actual := range names {
+			if !defaultTableLookup[logical] {
+				o.err = fmt.Errorf("tabela desconhecida: %q", logical)
+				return
+			}
+			if !identifierPattern.MatchString(actual) {
+				o.err = fmt.Errorf("nome de tabela inválido para %s: %q", logical, actual)
+				return
+			}
+			o.tableNames[logical] = actual
+		}
+	}
+}
+
+// resolveTables monta o nome real de cada tabela a partir das opções.
+func resolveTables(o options) (map[string]string, error) {
+	tables := make(map[string]string, len(TableNames))
+	seen := make(map[string]string, len(TableNames))
+	for _, logical := range TableNames {
+		actual := o.tablePrefix + logical
+		if name, ok := o.tableNames[logical]; ok {
+			actual = name
+		}
+		if other, ok := seen[actual]; ok {
+			return nil, fmt.Errorf("tabelas %s e %s com o mesmo nome: %s", other, logical, actual)
+		}
+		seen[actual] = logical
+		tables[logical] = actual
+	}
+	return tables, nil
+}
+
+// table devolve o nome real de uma tabela a partir do nome lógico.
+func (d *Database) table(logical string) string {
+	if name, ok := d.tables[logical]; ok {
+		return name
+	}
+	return logical
+}
+
+// Tables devolve o nome real de cada tabela, indexado pelo nome lógico.
+func (d *Database) Tables() map[string]string {
+	tables := make(map[string]string, len(d.tables))
+	for logical, actual := range d.tables {
+		tables[logical] = actual
+	}
+	return tables
+}
+
+// expandTables troca os marcadores {{tabela}} das migrações pelos nomes
+// reais.
+func (d *Database) expandTables(sql string) (string, error) {
+	var unknown []string
+	expanded := tablePlaceholder.ReplaceAllStringFunc(sql, func(m string) string {
+		logical := strings.Trim(m, "{}")
+		if !defaultTableLookup[logical] {
+			unknown = append(unknown, logical)
+			return m
+		}
+		return d.table(logical)
+	})
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("tabelas desconhecidas na migração: %s", strings.Join(unknown, ", "))
+	}
+	return expanded, nil
+}
@@ -0,0 +1,496 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// Tx expõe os métodos de gravação de Database dentro de uma única
+// transação, aberta por WithTx. Os métodos usam o contexto informado a
+// WithTx.
+type Tx struct {
+	ctx context.Context
+	tx  *sql.Tx
+	d   *Database
+}
+
+// WithTx executa fn em uma transação. Se fn devolver erro (ou entrar em
+// pânico), a transação é desfeita; caso contrário, é confirmada. Como as
+// gravações dependem do esquema mais recente, WithTx (e portanto todo
+// método de gravação) falha com um erro claro enquanto houver migrações
+// pendentes.
+//
+//	err := db.WithTx(ctx, func(tx *database.Tx) error {
+//		if err := tx.SaveGenres(genres); err != nil {
+//			return err
+//		}
+//		if err := tx.SaveMoviesBulk(movies); err != nil {
+//			return err
+//		}
+//		_, err := tx.SQL().ExecContext(ctx, "UPDATE sync_state SET page = ?", page)
+//		return err
+//	})
+func (d *Database) WithTx(ctx context.Context, fn func(tx *Tx) error) error {
+	if err := d.requireSchema(ctx); err != nil {
+		return err
+	}
+	sqlTx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+	if err := fn(&Tx{ctx: ctx, tx: sqlTx, d: d}); err != nil {
+		sqlTx.Rollback()
+		return err
+	}
+	return sqlTx.Commit()
+}
+
+// InTx é WithTx para código que recebe um Store: fn recebe o próprio Tx
+// como TxStore.
+func (d *Database) InTx(ctx context.Context, fn func(tx TxStore) error) error {
+	return d.WithTx(ctx, func(tx *Tx) error {
+		return fn(tx)
+	})
+}
+
+// SQL devolve a transação subjacente, para gravar tabelas da aplicação na
+// mesma transação. Commit e Rollback ficam a cargo de WithTx.
+func (tx *Tx) SQL() *sql.Tx {
+	return tx.tx
+}
+
+// Dialect devolve o dialeto do banco, útil para montar comandos com
+// Dialect().Rebind.
+func (tx *Tx) Dialect() Dialect {
+	return tx.d.dialect
+}
+
+func (tx *Tx) SaveMovie(movie *models.Movie) error {
+	movies := []models.Movie{*movie}
+	if err := tx.SaveMoviesBulk(movies); err != nil {
+		return err
+	}
+	movie.CreatedAt = movies[0].CreatedAt
+	movie.UpdatedAt = movies[0].UpdatedAt
+	return nil
+}
+
+func (tx *Tx) SaveTVShow(show *models.TVShow) error {
+	shows := []models.TVShow{*show}
+	if err := tx.SaveTVShowsBulk(shows); err != nil {
+		return err
+	}
+	show.CreatedAt = shows[0].CreatedAt
+	show.UpdatedAt = shows[0].UpdatedAt
+	return nil
+}
+
+// SaveMoviesBulk segue as regras de Database.SaveMoviesBulk.
+func (tx *Tx) SaveMoviesBulk(movies []models.Movie) error {
+	_, err := tx.SaveMoviesBulkWithResult(movies)
+	return err
+}
+
+// SaveMoviesBulkWithResult segue as regras de
+// Database.SaveMoviesBulkWithResult.
+func (tx *Tx) SaveMoviesBulkWithResult(movies []models.Movie) (SaveResult, error) {
+	var result SaveResult
+	w, err := tx.prepareMediaWriter(moviesTable, tx.d.movieUpsert())
+	if err != nil {
+		return result, err
+	}
+	defer w.close()
+
+	now := time.Now()
+	for i := range movies {
+		movie := &movies[i]
+		err := w.save(tx.ctx, &result, now, mediaItem{
+			id:        movie.ID,
+			hash:      movieHash(movie),
+			genreIDs:  movie.GenreIDs,
+			createdAt: &movie.CreatedAt,
+			updatedAt: &movie.UpdatedAt,
+			values:    func() []string { return movieAuditValues(movie) },
+			args: func(hash string) []interface{} {
+				return []interface{}{movie.ID, movie.Title, movie.OriginalTitle, movie.Overview,
+					movie.ReleaseDate, movie.PosterPath, movie.BackdropPath, movie.VoteAverage, movie.TrailerURL,
+					movie.Popularity, hash, models.StatusActive, nil, movie.UpdatedAt, movie.CreatedAt}
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// SaveTVShowsBulk segue as regras de Database.SaveTVShowsBulk.
+func (tx *Tx) SaveTVShowsBulk(shows []models.TVShow) error {
+	_, err := tx.SaveTVShowsBulkWithResult(shows)
+	return err
+}
+
+// SaveTVShowsBulkWithResult segue as regras de
+// Database.SaveTVShowsBulkWithResult.
+func (tx *Tx) SaveTVShowsBulkWithResult(shows []models.TVShow) (SaveResult, error) {
+	var result SaveResult
+	w, err := tx.prepareMediaWriter(tvShowsTable, tx.d.tvShowUpsert())
+	if err != nil {
+		return result, err
+	}
+	defer w.close()
+
+	now := time.Now()
+	for i := range shows {
+		show := &shows[i]
+		err := w.save(tx.ctx, &result, now, mediaItem{
+			id:        show.ID,
+			hash:      tvShowHash(show),
+			genreIDs:  show.GenreIDs,
+			createdAt: &show.CreatedAt,
+			updatedAt: &show.UpdatedAt,
+			values:    func() []string { return tvShowAuditValues(show) },
+			args: func(hash string) []interface{} {
+				return []interface{}{show.ID, show.Name, show.OriginalName, show.Overview,
+					show.FirstAirDate, show.PosterPath, show.BackdropPath, show.VoteAverage, show.TrailerURL,
+					show.Popularity, hash, models.StatusActive, nil, show.UpdatedAt, show.CreatedAt}
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// mediaItem é um filme ou série a gravar por mediaWriter.save. createdAt e
+// updatedAt recebem as datas gravadas; args monta os argumentos do upsert
+// depois disso, e values devolve os campos auditados.
+type mediaItem struct {
+	id        int
+	hash      string
+	genreIDs  []int
+	createdAt *time.Time
+	updatedAt *time.Time
+	values    func() []string
+	args      func(hash string) []interface{}
+}
+
+// mediaWriter guarda os comandos preparados para gravar filmes ou séries:
+// a consulta do hash atual, o upsert, a atualização de updated_at quando só
+// os gêneros mudam, as relações de gênero, o índice de busca e, no modo de
+// auditoria, a leitura dos valores antigos e o registro
+// das mudanças.
+type mediaWriter struct {
+	table    mediaTable
+	current  *sql.Stmt
+	upsert   *sql.Stmt
+	touch    *sql.Stmt
+	search   *sql.Stmt
+	previous *sql.Stmt
+	changes  *sql.Stmt
+	links    *genreLinks
+}
+
+func (tx *Tx) prepareMediaWriter(t mediaTable, upsert string) (*mediaWriter, error) {
+	w := &mediaWriter{table: t}
+	var err error
+	if w.current, err = tx.tx.PrepareContext(tx.ctx, tx.d.dialect.Rebind(
+		`SELECT content_hash, created_at, updated_at, deleted_at FROM `+tx.d.table(t.table)+` WHERE id = ?`)); err != nil {
+		return nil, err
+	}
+	if w.upsert, err = tx.tx.PrepareContext(tx.ctx, upsert); err != nil {
+		w.close()
+		return nil, err
+	}
+	if w.touch, err = tx.tx.PrepareContext(tx.ctx, tx.d.dialect.Rebind(
+		`UPDATE `+tx.d.table(t.table)+` SET updated_at = ? WHERE id = ?`)); err != nil {
+		w.close()
+		return nil, err
+	}
+	if w.links, err = tx.prepareGenreLinks(t); err != nil {
+		w.close()
+		return nil, err
+	}
+	if w.search, err = tx.prepareSearchRefresh(t); err != nil {
+		w.close()
+		return nil, err
+	}
+	if tx.d.audit {
+		if w.previous, err = tx.tx.PrepareContext(tx.ctx, tx.d.auditSelect(t)); err != nil {
+			w.close()
+			return nil, err
+		}
+		if w.changes, err = tx.tx.PrepareContext(tx.ctx, tx.d.changeInsert()); err != nil {
+			w.close()
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// save grava um item se o hash do conteúdo ou as relações de gênero
+// mudaram, ou se ele estava removido, e conta o resultado. Itens gravados
+// recebem now como UpdatedAt; CreatedAt é now só em inserções e, nos
+// demais casos, a data do banco, que o upsert preserva.
+func (w *mediaWriter) save(ctx context.Context, result *SaveResult, now time.Time, item mediaItem) error {
+	var stored sql.NullString
+	var created, updated, deleted sql.NullTime
+	err := w.current.QueryRowContext(ctx, item.id).Scan(&stored, &created, &updated, &deleted)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	exists := err == nil
+
+	// Itens marcados como removidos são sempre regravados, o que os
+	// reativa.
+	if exists && stored.Valid && stored.String == item.hash && !deleted.Valid {
+		*item.createdAt = created.Time
+		*item.updatedAt = updated.Time
+		current, err := w.links.current(ctx, item.id)
+		if err != nil {
+			return err
+		}
+		if sameGenreIDs(current, item.genreIDs) {
+			result.Unchanged++
+			return nil
+		}
+		*item.updatedAt = now
+		if _, err := w.touch.ExecContext(ctx, now, item.id); err != nil {
+			return err
+		}
+		result.Updated++
+		return w.links.replace(ctx, item.id, item.genreIDs)
+	}
+
+	var old []string
+	if exists && w.previous != nil {
+		if old, err = scanAuditValues(w.previous.QueryRowContext(ctx, item.id), len(w.table.auditFields())); err != nil {
+			return err
+		}
+	}
+
+	*item.createdAt = now
+	if exists {
+		*item.createdAt = created.Time
+	}
+	*item.updatedAt = now
+	if _, err := w.upsert.ExecContext(ctx, item.args(item.hash)...); err != nil {
+		return err
+	}
+	if err := w.links.replace(ctx, item.id, item.genreIDs); err != nil {
+		return err
+	}
+	if _, err := w.search.ExecContext(ctx, item.id); err != nil {
+		return err
+	}
+	if old != nil {
+		if err := recordChanges(ctx, w.changes, w.table.mediaType, item.id, w.table.auditFields(), old, item.values(), now.UTC()); err != nil {
+			return err
+		}
+	}
+	if exists {
+		result.Updated++
+	} else {
+		result.Inserted++
+	}
+	return nil
+}
+
+func (w *mediaWriter) close() {
+	for _, stmt := range []*sql.Stmt{w.current, w.upsert, w.touch, w.search, w.previous, w.changes} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+	if w.links != nil {
+		w.links.close()
+	}
+}
+
+// genreLinks guarda os comandos preparados para substituir as relações de
+// gênero de um item dentro de uma transação.
+type genreLinks struct {
+	sel *sql.Stmt
+	del *sql.Stmt
+	ins *sql.Stmt
+}
+
+func (tx *Tx) prepareGenreLinks(t mediaTable) (*genreLinks, error) {
+	dialect := tx.d.dialect
+	table := tx.d.table(t.genreTable)
+	sel, err := tx.tx.PrepareContext(tx.ctx, dialect.Rebind(fmt.Sprintf("SELECT genre_id FROM %s WHERE %s = ? ORDER BY genre_id", table, t.genreFK)))
+	if err != nil {
+		return nil, err
+	}
+	del, err := tx.tx.PrepareContext(tx.ctx, dialect.Rebind(fmt.Sprintf("DELETE FROM %s WHERE %s = ?", table, t.genreFK)))
+	if err != nil {
+		sel.Close()
+		return nil, err
+	}
+	ins, err := tx.tx.PrepareContext(tx.ctx, dialect.InsertIgnore(table, []string{t.genreFK, "genre_id"}, []string{t.genreFK, "genre_id"}))
+	if err != nil {
+		sel.Close()
+		del.Close()
+		return nil, err
+	}
+	return &genreLinks{sel: sel, del: del, ins: ins}, nil
+}
+
+// current devolve os gêneros associados hoje a um item, em ordem.
+func (l *genreLinks) current(ctx context.Context, id int) ([]int, error) {
+	rows, err := l.sel.QueryContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int
+	for rows.Next() {
+		var genreID int
+		if err := rows.Scan(&genreID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, genreID)
+	}
+	return ids, rows.Err()
+}
+
+func (l *genreLinks) replace(ctx context.Context, id int, genreIDs []int) error {
+	if genreIDs == nil {
+		return nil
+	}
+	if _, err := l.del.ExecContext(ctx, id); err != nil {
+		return err
+	}
+	for _, genreID := range genreIDs {
+		if _, err := l.ins.ExecContext(ctx, id, genreID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *genreLinks) close() {
+	l.sel.Close()
+	l.del.Close()
+	l.ins.Close()
+}
+
+// prepareSearchRefresh prepara o comando que regrava um item no índice de
+// busca a partir do seu ID.
+func (tx *Tx) prepareSearchRefresh(t mediaTable) (*sql.Stmt, error) {
+	return tx.tx.PrepareContext(tx.ctx, tx.d.refreshSearchSQL(t, "id = ?"))
+}
+
+// execEach prepara query uma vez e a executa com cada conjunto de
+// argumentos.
+func (tx *Tx) execEach(query string, n int, args func(i int) []interface{}) error {
+	stmt, err := tx.tx.PrepareContext(tx.ctx, query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i := 0; i < n; i++ {
+		if _, err := stmt.ExecContext(tx.ctx, args(i)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveGenres segue as regras de Database.SaveGenres.
+func (tx *Tx) SaveGenres(genres []models.Genre) error {
+	query := tx.d.dialect.Upsert(tx.d.table("genres"), []string{"id", "media_type", "language", "name"},
+		[]string{"id", "media_type", "language"}, []string{"name"})
+	return tx.execEach(query, len(genres), func(i int) []interface{} {
+		return []interface{}{genres[i].ID, genreMediaType(genres[i]), genres[i].Language, genres[i].Name}
+	})
+}
+
+// genreMediaType devolve o MediaType do gênero, ou models.MediaTypeMovie
+// para gêneros montados sem ele, como nas versões em que a tabela genres
+// era só de filmes.
+func genreMediaType(g models.Genre) string {
+	if g.MediaType == "" {
+		return models.MediaTypeMovie
+	}
+	return g.MediaType
+}
+
+func (tx *Tx) SaveMovieGenres(movieID int, genreIDs []int) error {
+	return tx.execEach(tx.d.movieGenreInsert(), len(genreIDs), func(i int) []interface{} {
+		return []interface{}{movieID, genreIDs[i]}
+	})
+}
+
+func (tx *Tx) SaveTVShowGenres(tvShowID int, genreIDs []int) error {
+	return tx.execEach(tx.d.tvShowGenreInsert(), len(genreIDs), func(i int) []interface{} {
+		return []interface{}{tvShowID, genreIDs[i]}
+	})
+}
+
+func (tx *Tx) SaveMovieGenresBulk(relations []models.MovieGenre) error {
+	return tx.execEach(tx.d.movieGenreInsert(), len(relations), func(i int) []interface{} {
+		return []interface{}{relations[i].MovieID, relations[i].GenreID}
+	})
+}
+
+func (tx *Tx) SaveTVShowGenresBulk(relations []models.TVShowGenre) error {
+	return tx.execEach(tx.d.tvShowGenreInsert(), len(relations), func(i int) []interface{} {
+		return []interface{}{relations[i].TVShowID, relations[i].GenreID}
+	})
+}
+
+// SaveVideos substitui a lista de vídeos de um filme ou série.
+func (tx *Tx) SaveVideos(mediaType string, mediaID int, videos []models.Video) error {
+	dialect := tx.d.dialect
+	if _, err := tx.tx.ExecContext(tx.ctx, dialect.Rebind(`DELETE FROM `+tx.d.table("videos")+` WHERE media_type = ? AND media_id = ?`), mediaType, mediaID); err != nil {
+		return err
+	}
+	query := dialect.Upsert(tx.d.table("videos"), videoColumns, []string{"id"}, videoColumns[1:])
+	return tx.execEach(query, len(videos), func(i int) []interface{} {
+		v := videos[i]
+		return []interface{}{v.ID, mediaType, mediaID, v.Key, v.Name, v.Site, v.Type,
+			v.Size, v.Official, v.PublishedAt, v.Language, v.Country}
+	})
+}
+
+func (tx *Tx) UpdateMovieTrailer(movieID int, trailerURL string) error {
+	return tx.updateTrailer(moviesTable, movieID, trailerURL)
+}
+
+func (tx *Tx) UpdateTVShowTrailer(showID int, trailerURL string) error {
+	return tx.updateTrailer(tvShowsTable, showID, trailerURL)
+}
+
+func (tx *Tx) SaveCountries(countries []models.Country) error {
+	query := tx.d.dialect.Upsert(tx.d.table("countries"), []string{"iso_3166_1", "english_name", "native_name"},
+		[]string{"iso_3166_1"}, []string{"english_name", "native_name"})
+	return tx.execEach(query, len(countries), func(i int) []interface{} {
+		return []interface{}{countries[i].ISO3166_1, countries[i].EnglishName, countries[i].NativeName}
+	})
+}
+
+func (tx *Tx) SaveLanguages(languages []models.Language) error {
+	query := tx.d.dialect.Upsert(tx.d.table("languages"), []string{"iso_639_1", "english_name", "name"},
+		[]string{"iso_639_1"}, []string{"english_name", "name"})
+	return tx.execEach(query, len(languages), func(i int) []interface{} {
+		return []interface{}{languages[i].ISO639_1, languages[i].EnglishName, languages[i].Name}
+	})
+}
+
+func (tx *Tx) SaveLocalImage(img *models.LocalImage) error {
+	_, err := tx.tx.ExecContext(tx.ctx, tx.d.dialect.Upsert(tx.d.table("images"), imageColumns, []string{"tmdb_path", "size"}, imageColumns[2:]),
+		img.Path, img.Size, img.Kind, img.LocalPath, img.SHA256, img.Bytes, img.DownloadedAt)
+	return err
+}
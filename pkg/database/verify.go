@@ -0,0 +1,391 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Tipos de problema encontrados por Verify.
+const (
+	IssueTable      = "table"
+	IssueColumn     = "column"
+	IssueIndex      = "index"
+	IssueForeignKey = "foreign_key"
+	IssueMigrations = "migrations"
+)
+
+// SchemaIssue é uma divergência entre o banco conectado e o esquema
+// esperado pela biblioteca. Fix traz o SQL que corrige o problema, quando
+// há uma correção segura.
+type SchemaIssue struct {
+	Kind    string
+	Table   string
+	Name    string
+	Message string
+	Fix     string
+}
+
+func (i SchemaIssue) String() string {
+	if i.Table == "" {
+		return fmt.Sprintf("%s: %s", i.Kind, i.Message)
+	}
+	return fmt.Sprintf("%s %s: %s", i.Kind, i.Table, i.Message)
+}
+
+// VerifyReport é o resultado de Verify.
+type VerifyReport struct {
+	Dialect string
+	// Version é a versão registrada em schema_migrations e Latest a última
+	// migração embutida na biblioteca.
+	Version int
+	Latest  int
+	Issues  []SchemaIssue
+}
+
+// OK indica que o banco corresponde ao esquema esperado.
+func (r *VerifyReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// FixSQL junta, na ordem dos problemas, o SQL que corrige o esquema.
+func (r *VerifyReport) FixSQL() string {
+	var stmts []string
+	for _, issue := range r.Issues {
+		if issue.Fix != "" {
+			stmts = append(stmts, issue.Fix)
+		}
+	}
+	if len(stmts) == 0 {
+		return ""
+	}
+	return strings.Join(stmts, ";\n\n") + ";\n"
+}
+
+// Verify compara o banco conectado com o esquema que as migrações produzem e
+// relata tabelas, colunas, índices e chaves estrangeiras ausentes. Serve
+// para diagnosticar bancos criados à mão ou alterados fora da biblioteca.
+//
+// Com migrações pendentes, as diferenças são esperadas: elas são relatadas
+// sem correção, e o caminho é executar Migrate. Sem schema_migrations, a
+// correção também registra todas as migrações, adotando o banco.
+func (d *Database) Verify(ctx context.Context) (*VerifyReport, error) {
+	migrations, err := loadMigrations(d.dialect)
+	if err != nil {
+		return nil, err
+	}
+	report := &VerifyReport{Dialect: d.dialect.Name()}
+	if len(migrations) > 0 {
+		report.Latest = migrations[len(migrations)-1].Version
+	}
+	tracked, err := d.tableExists(ctx, d.table("schema_migrations"))
+	if err != nil {
+		return nil, err
+	}
+	if tracked {
+		if report.Version, err = d.VersionContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, t := range expectedSchema {
+		issues, err := d.verifyTable(ctx, t)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao verificar %s: %v", d.table(t.name), err)
+		}
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	switch {
+	case !tracked:
+		report.Issues = append(report.Issues, SchemaIssue{
+			Kind:    IssueMigrations,
+			Table:   d.table("schema_migrations"),
+			Message: "tabela de migrações ausente; o banco não foi criado por Migrate",
+			Fix:     d.adoptSQL(migrations),
+		})
+	case report.Version < report.Latest:
+		for i := range report.Issues {
+			report.Issues[i].Fix = ""
+		}
+		report.Issues = append(report.Issues, SchemaIssue{
+			Kind:    IssueMigrations,
+			Table:   d.table("schema_migrations"),
+			Message: fmt.Sprintf("migrações pendentes (versão %d de %d); execute Migrate", report.Version, report.Latest),
+		})
+	case report.Version > report.Latest:
+		report.Issues = append(report.Issues, SchemaIssue{
+			Kind:    IssueMigrations,
+			Table:   d.table("schema_migrations"),
+			Message: fmt.Sprintf("banco na versão %d, mais nova que a da biblioteca (%d)", report.Version, report.Latest),
+		})
+	}
+	return report, nil
+}
+
+func (d *Database) verifyTable(ctx context.Context, t schemaTable) ([]SchemaIssue, error) {
+	table := d.table(t.name)
+	exists, err := d.tableExists(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		fix, err := d.createTableSQL(ctx, t)
+		if err != nil {
+			return nil, err
+		}
+		return []SchemaIssue{{Kind: IssueTable, Table: table, Name: table, Message: "tabela ausente", Fix: fix}}, nil
+	}
+	// O índice de busca do SQLite é uma tabela virtual, cujas colunas e
+	// índices internos dependem do módulo FTS.
+	if d.dialect == SQLite && t.sqliteVirtual {
+		return nil, nil
+	}
+
+	info, err := d.inspectTable(ctx, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []SchemaIssue
+	for _, c := range t.columns {
+		if info.columns[strings.ToLower(c.name)] {
+			continue
+		}
+		issues = append(issues, SchemaIssue{
+			Kind:    IssueColumn,
+			Table:   table,
+			Name:    c.name,
+			Message: "coluna ausente: " + c.name,
+			Fix:     fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, c.name, d.addColumnType(c)),
+		})
+	}
+	for _, idx := range t.indexes {
+		if idx.notSQLite && d.dialect == SQLite {
+			continue
+		}
+		if info.hasIndex(idx.columns) {
+			continue
+		}
+		issues = append(issues, SchemaIssue{
+			Kind:    IssueIndex,
+			Table:   table,
+			Name:    indexName(table, idx),
+			Message: fmt.Sprintf("índice ausente em (%s)", strings.Join(idx.columns, ", ")),
+			Fix:     d.createIndexSQL(table, idx),
+		})
+	}
+	for _, fk := range t.foreignKeys {
+		ref := d.table(fk.refTable)
+		if info.hasForeignKey(fk.column, ref) {
+			continue
+		}
+		issue := SchemaIssue{
+			Kind:    IssueForeignKey,
+			Table:   table,
+			Name:    fk.column,
+			Message: fmt.Sprintf("chave estrangeira ausente: %s -> %s(id)", fk.column, ref),
+		}
+		if d.dialect == SQLite {
+			issue.Message += "; no SQLite é preciso recriar a tabela"
+		} else {
+			issue.Fix = fmt.Sprintf("ALTER TABLE %s ADD FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE CASCADE", table, fk.column, ref)
+		}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// tableInfo é a estrutura de uma tabela lida do catálogo do banco.
+type tableInfo struct {
+	columns     map[string]bool
+	indexes     [][]string
+	foreignKeys map[string]string // coluna -> tabela referenciada
+}
+
+func (t *tableInfo) hasIndex(columns []string) bool {
+	for _, idx := range t.indexes {
+		if len(idx) != len(columns) {
+			continue
+		}
+		match := true
+		for i := range idx {
+			if !strings.EqualFold(idx[i], columns[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *tableInfo) hasForeignKey(column, ref string) bool {
+	return strings.EqualFold(t.foreignKeys[strings.ToLower(column)], ref)
+}
+
+func (d *Database) inspectTable(ctx context.Context, table string) (*tableInfo, error) {
+	var columnsQuery, indexesQuery, foreignKeysQuery string
+	switch d.dialect {
+	case PostgreSQL:
+		columnsQuery = `SELECT column_name FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = ?`
+		indexesQuery = `SELECT indexname, indexdef FROM pg_indexes
+			WHERE schemaname = current_schema() AND tablename = ?`
+		foreignKeysQuery = `SELECT kcu.column_name, ccu.table_name
+			FROM information_schema.table_constraints tc
+			JOIN information_schema.key_column_usage kcu
+				ON kcu.constraint_name = tc.constraint_name AND kcu.table_schema = tc.table_schema
+			JOIN information_schema.constraint_column_usage ccu
+				ON ccu.constraint_name = tc.constraint_name AND ccu.table_schema = tc.table_schema
+			WHERE tc.constraint_type = 'FOREIGN KEY' AND tc.table_schema = current_schema() AND tc.table_name = ?`
+	case MySQL:
+		columnsQuery = `SELECT column_name FROM information_schema.columns
+			WHERE table_schema = DATABASE() AND table_name = ?`
+		indexesQuery = `SELECT index_name, column_name FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = ? ORDER BY index_name, seq_in_index`
+		foreignKeysQuery = `SELECT column_name, referenced_table_name FROM information_schema.key_column_usage
+			WHERE table_schema = DATABASE() AND table_name = ? AND referenced_table_name IS NOT NULL`
+	default:
+		columnsQuery = `SELECT name FROM pragma_table_info(?)`
+		indexesQuery = `SELECT il.name, ii.name FROM pragma_index_list(?) il
+			JOIN pragma_index_info(il.name) ii ORDER BY il.name, ii.seqno`
+		foreignKeysQuery = `SELECT "from", "table" FROM pragma_foreign_key_list(?)`
+	}
+
+	info := &tableInfo{columns: make(map[string]bool), foreignKeys: make(map[string]string)}
+	if err := d.queryPairs(ctx, columnsQuery, table, func(name, _ string) {
+		info.columns[strings.ToLower(name)] = true
+	}); err != nil {
+		return nil, err
+	}
+
+	var order []string
+	indexes := make(map[string][]string)
+	if err := d.queryPairs(ctx, indexesQuery, table, func(name, value string) {
+		if _, ok := indexes[name]; !ok {
+			order = append(order, name)
+		}
+		if d.dialect == PostgreSQL {
+			indexes[name] = indexDefColumns(value)
+		} else {
+			indexes[name] = append(indexes[name], value)
+		}
+	}); err != nil {
+		return nil, err
+	}
+	for _, name := range order {
+		info.indexes = append(info.indexes, indexes[name])
+	}
+
+	if err := d.queryPairs(ctx, foreignKeysQuery, table, func(column, ref string) {
+		info.foreignKeys[strings.ToLower(column)] = ref
+	}); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// queryPairs executa uma consulta de introspecção com a tabela como único
+// argumento e entrega as duas primeiras colunas de cada linha (a segunda
+// vazia quando a consulta só tem uma).
+func (d *Database) queryPairs(ctx context.Context, query, table string, fn func(a, b string)) error {
+	rows, err := d.db.QueryContext(ctx, d.dialect.Rebind(query), table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var a, b string
+		dest := []any{&a, &b}
+		if err := rows.Scan(dest[:len(cols)]...); err != nil {
+			return err
+		}
+		fn(a, b)
+	}
+	return rows.Err()
+}
+
+// indexDefColumns extrai as colunas de um indexdef do PostgreSQL, como
+// "CREATE INDEX i ON public.t USING gin (document)".
+func indexDefColumns(def string) []string {
+	start := strings.LastIndex(def, "(")
+	end := strings.LastIndex(def, ")")
+	if start < 0 || end < start {
+		return nil
+	}
+	var columns []string
+	for _, c := range strings.Split(def[start+1:end], ",") {
+		columns = append(columns, strings.Trim(strings.TrimSpace(c), `"`))
+	}
+	return columns
+}
+
+func indexName(table string, idx schemaIndex) string {
+	return "idx_" + table + "_" + idx.name
+}
+
+func (d *Database) createIndexSQL(table string, idx schemaIndex) string {
+	kind, using := "", ""
+	if d.dialect == PostgreSQL && idx.postgresUsing != "" {
+		using = " USING " + idx.postgresUsing
+	}
+	if d.dialect == MySQL && idx.mysqlKind != "" {
+		kind = idx.mysqlKind + " "
+	}
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s%s (%s)", kind, indexName(table, idx), table, using, strings.Join(idx.columns, ", "))
+}
+
+func (d *Database) createTableSQL(ctx context.Context, t schemaTable) (string, error) {
+	if d.dialect == SQLite && t.sqliteVirtual {
+		return d.sqliteSearchIndexDDL(ctx)
+	}
+	table := d.table(t.name)
+	var lines []string
+	for _, c := range t.columns {
+		lines = append(lines, "    "+c.name+" "+c.typeFor(d.dialect))
+	}
+	lines = append(lines, "    PRIMARY KEY ("+strings.Join(t.primaryKey, ", ")+")")
+	for _, fk := range t.foreignKeys {
+		lines = append(lines, fmt.Sprintf("    FOREIGN KEY (%s) REFERENCES %s(id) ON DELETE CASCADE", fk.column, d.table(fk.refTable)))
+	}
+	stmts := []string{"CREATE TABLE " + table + " (\n" + strings.Join(lines, ",\n") + "\n)"}
+	for _, idx := range t.indexes {
+		if idx.notSQLite && d.dialect == SQLite {
+			continue
+		}
+		stmts = append(stmts, d.createIndexSQL(table, idx))
+	}
+	return strings.Join(stmts, ";\n\n"), nil
+}
+
+// addColumnType adapta o tipo de uma coluna ao ALTER TABLE: NOT NULL sem
+// DEFAULT falharia em tabelas com linhas, e o SQLite não aceita DEFAULT não
+// constante em colunas adicionadas.
+func (d *Database) addColumnType(c schemaColumn) string {
+	typ := c.typeFor(d.dialect)
+	if d.dialect == SQLite {
+		typ = strings.Replace(typ, " DEFAULT CURRENT_TIMESTAMP", "", 1)
+	}
+	if !strings.Contains(typ, "DEFAULT") {
+		typ = strings.Replace(typ, " NOT NULL", "", 1)
+	}
+	return typ
+}
+
+// adoptSQL cria schema_migrations e registra todas as migrações, para que um
+// banco já corrigido seja tratado como atualizado por Migrate.
+func (d *Database) adoptSQL(migrations []Migration) string {
+	stmts := []string{d.migrationsTableSQL()}
+	for _, m := range migrations {
+		stmts = append(stmts, fmt.Sprintf("INSERT INTO %s (version, name, applied_at) VALUES (%d, '%s', CURRENT_TIMESTAMP)",
+			d.table("schema_migrations"), m.Version, strings.ReplaceAll(m.Name, "'", "''")))
+	}
+	return strings.Join(stmts, ";\n")
+}
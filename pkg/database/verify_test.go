@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func newMigratedDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := NewDatabase(filepath.Join(t.TempDir(), "tmdb.db"), WithAutoMigrate())
+	if err != nil {
+		t.Fatalf("NewDatabase: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func mustVerify(t *testing.T, db *Database) *VerifyReport {
+	t.Helper()
+	report, err := db.Verify(context.Background())
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	return report
+}
+
+// applyFix executa o SQL de FixSQL, como faria quem corrige o banco à mão.
+func applyFix(t *testing.T, db *Database, report *VerifyReport) {
+	t.Helper()
+	for _, stmt := range splitStatements(report.FixSQL()) {
+		if _, err := db.db.Exec(stmt); err != nil {
+			t.Fatalf("Exec(%q): %v", stmt, err)
+		}
+	}
+}
+
+func TestVerifyMigrated(t *testing.T) {
+	report := mustVerify(t, newMigratedDatabase(t))
+	if !report.OK() {
+		t.Errorf("Verify relatou problemas num banco migrado: %v", report.Issues)
+	}
+	if report.Version != report.Latest || report.Latest == 0 {
+		t.Errorf("Version = %d, Latest = %d", report.Version, report.Latest)
+	}
+	if sql := report.FixSQL(); sql != "" {
+		t.Errorf("FixSQL = %q, esperado vazio", sql)
+	}
+}
+
+func TestVerifyFix(t *testing.T) {
+	tests := []struct {
+		name string
+		drop string
+		want SchemaIssue
+	}{
+		{
+			name: "coluna",
+			drop: "ALTER TABLE movies DROP COLUMN trailer_checked_at",
+			want: SchemaIssue{Kind: IssueColumn, Table: "movies", Name: "trailer_checked_at",
+				Fix: "ALTER TABLE movies ADD COLUMN trailer_checked_at DATETIME"},
+		},
+		{
+			name: "coluna NOT NULL com DEFAULT",
+			drop: "ALTER TABLE tv_shows DROP COLUMN status",
+			want: SchemaIssue{Kind: IssueColumn, Table: "tv_shows", Name: "status",
+				Fix: "ALTER TABLE tv_shows ADD COLUMN status TEXT NOT NULL DEFAULT 'active'"},
+		},
+		{
+			name: "índice",
+			drop: "DROP INDEX idx_videos_media",
+			want: SchemaIssue{Kind: IssueIndex, Table: "videos", Name: "idx_videos_media",
+				Fix: "CREATE INDEX idx_videos_media ON videos (media_type, media_id)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newMigratedDatabase(t)
+			if _, err := db.db.Exec(tt.drop); err != nil {
+				t.Fatalf("Exec(%q): %v", tt.drop, err)
+			}
+			report := mustVerify(t, db)
+			if len(report.Issues) != 1 {
+				t.Fatalf("Issues = %v, esperado um problema", report.Issues)
+			}
+			got := report.Issues[0]
+			if got.Kind != tt.want.Kind || got.Table != tt.want.Table || got.Name != tt.want.Name || got.Fix != tt.want.Fix {
+				t.Errorf("Issue = %+v, esperado %+v", got, tt.want)
+			}
+			if sql := report.FixSQL(); sql != tt.want.Fix+";\n" {
+				t.Errorf("FixSQL = %q", sql)
+			}
+			applyFix(t, db, report)
+			if report := mustVerify(t, db); !report.OK() {
+				t.Errorf("Verify depois da correção: %v", report.Issues)
+			}
+		})
+	}
+}
+
+// TestVerifyAdopt confere que, num banco com o esquema completo mas sem
+// schema_migrations, a correção registra as migrações e Migrate passa a
+// tratá-lo como atualizado.
+func TestVerifyAdopt(t *testing.T) {
+	db := newMigratedDatabase(t)
+	if _, err := db.db.Exec("DROP TABLE schema_migrations"); err != nil {
+		t.Fatalf("DROP TABLE: %v", err)
+	}
+	report := mustVerify(t, db)
+	if len(report.Issues) != 1 || report.Issues[0].Kind != IssueMigrations {
+		t.Fatalf("Issues = %v, esperado só a falta de schema_migrations", report.Issues)
+	}
+	migrations, err := loadMigrations(db.dialect)
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	if fix := report.Issues[0].Fix; fix != db.adoptSQL(migrations) {
+		t.Errorf("Fix = %q, esperado o SQL de adoção", fix)
+	}
+
+	applyFix(t, db, report)
+	report = mustVerify(t, db)
+	if !report.OK() || report.Version != report.Latest {
+		t.Errorf("Verify depois da adoção: Version = %d de %d, Issues = %v", report.Version, report.Latest, report.Issues)
+	}
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Errorf("Migrate depois da adoção: %v", err)
+	}
+}
@@ -0,0 +1,241 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+const defaultConcurrency = 4
+
+// DefaultSizes são os tamanhos baixados por tipo quando Mirror.Sizes não
+// define outro valor.
+var DefaultSizes = map[string][]string{
+	models.ImageKindPoster:   {"w342"},
+	models.ImageKindBackdrop: {"w780"},
+	models.ImageKindProfile:  {"w185"},
+	models.ImageKindLogo:     {"w185"},
+}
+
+// Mirror baixa as imagens referenciadas no banco para um diretório local.
+// Os arquivos são endereçados pelo SHA-256 do conteúdo (dir/ab/cd/<hash>.jpg),
+// então imagens idênticas ocupam um único arquivo, e cada par caminho/tamanho
+// é registrado na tabela images.
+type Mirror struct {
+	db     database.Store
+	dir    string
+	client *http.Client
+
+	// Sizes define os tamanhos baixados por tipo de imagem. Tipos ausentes
+	// usam DefaultSizes.
+	Sizes map[string][]string
+	// Concurrency limita os downloads simultâneos. Zero usa 4.
+	Concurrency int
+	// Images define as URLs e os tamanhos válidos de cada tipo, normalmente
+	// api.TMDBClient.ImageConfig. O valor zero usa models.DefaultImageConfig.
+	Images models.ImageConfig
+}
+
+type Result struct {
+	Downloaded int
+	Skipped    int
+	Errors     []error
+}
+
+func NewMirror(db database.Store, dir string) *Mirror {
+	return &Mirror{
+		db:     db,
+		dir:    dir,
+		client: &http.Client{Timeout: time.Minute},
+	}
+}
+
+// MirrorAll baixa, em todos os tamanhos configurados, as imagens de filmes e
+// séries salvos. Imagens já presentes no diretório são puladas e falhas
+// individuais são devolvidas em Result.Errors.
+func (m *Mirror) MirrorAll(ctx context.Context) (*Result, error) {
+	refs, err := m.db.ImagePathsContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.MirrorRefs(ctx, refs)
+}
+
+// MirrorRefs baixa as imagens informadas em todos os tamanhos configurados.
+func (m *Mirror) MirrorRefs(ctx context.Context, refs []models.ImageRef) (*Result, error) {
+	type job struct {
+		ref  models.ImageRef
+		size string
+	}
+	var jobs []job
+	for _, ref := range refs {
+		for _, size := range m.sizes(ref.Kind) {
+			jobs = append(jobs, job{ref, size})
+		}
+	}
+
+	concurrency := m.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	result := &Result{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(j job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			downloaded, err := m.mirror(ctx, j.ref.Kind, j.ref.Path, j.size)
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				result.Errors = append(result.Errors, fmt.Errorf("erro ao baixar %s (%s): %v", j.ref.Path, j.size, err))
+			case downloaded:
+				result.Downloaded++
+			default:
+				result.Skipped++
+			}
+		}(j)
+	}
+	wg.Wait()
+	return result, ctx.Err()
+}
+
+// Download garante a cópia local de uma imagem e devolve o registro
+// correspondente.
+func (m *Mirror) Download(ctx context.Context, kind, tmdbPath, size string) (*models.LocalImage, error) {
+	if _, err := m.mirror(ctx, kind, tmdbPath, size); err != nil {
+		return nil, err
+	}
+	return m.db.GetLocalImageContext(ctx, tmdbPath, size)
+}
+
+// LocalPath devolve o caminho absoluto de uma imagem registrada.
+func (m *Mirror) LocalPath(img *models.LocalImage) string {
+	return filepath.Join(m.dir, filepath.FromSlash(img.LocalPath))
+}
+
+func (m *Mirror) sizes(kind string) []string {
+	if sizes, ok := m.Sizes[kind]; ok {
+		return sizes
+	}
+	return DefaultSizes[kind]
+}
+
+func (m *Mirror) mirror(ctx context.Context, kind, tmdbPath, size string) (bool, error) {
+	existing, err := m.db.GetLocalImageContext(ctx, tmdbPath, size)
+	if err != nil {
+		return false, err
+	}
+	if existing != nil {
+		if _, err := os.Stat(m.LocalPath(existing)); err == nil {
+			return false, nil
+		}
+	}
+
+	cfg := m.imageConfig()
+	if !contains(cfg.Sizes(kind), size) {
+		return false, fmt.Errorf("tamanho %q inválido para imagens do tipo %q", size, kind)
+	}
+
+	img, err := m.fetch(ctx, cfg.URL(kind, tmdbPath, size), path.Ext(tmdbPath))
+	if err != nil {
+		return false, err
+	}
+	img.Path = tmdbPath
+	img.Size = size
+	img.Kind = kind
+	if err := m.db.SaveLocalImageContext(ctx, img); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (m *Mirror) imageConfig() models.ImageConfig {
+	if m.Images.BaseURL == "" && m.Images.SecureBaseURL == "" {
+		return models.DefaultImageConfig
+	}
+	return m.Images
+}
+
+// fetch baixa a imagem para um arquivo temporário calculando o hash e depois
+// move o arquivo para o caminho endereçado pelo conteúdo.
+func (m *Mirror) fetch(ctx context.Context, url, ext string) (*models.LocalImage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("erro na requisição: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("erro ao baixar imagem (status %d)", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(m.dir, ".download-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao gravar imagem: %v", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	rel := path.Join(sum[:2], sum[2:4], sum+ext)
+	dest := filepath.Join(m.dir, filepath.FromSlash(rel))
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmp.Name(), dest); err != nil {
+			return nil, err
+		}
+	}
+
+	return &models.LocalImage{
+		LocalPath:    rel,
+		SHA256:       sum,
+		Bytes:        n,
+		DownloadedAt: time.Now(),
+	}, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
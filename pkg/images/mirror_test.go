@@ -0,0 +1,132 @@
+package images
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/database"
+	"github.com/sshturbo/TMDB-Collector-Lib/pkg/models"
+)
+
+// imageServer serve o mesmo conteúdo para /t/p/<tamanho>/a.jpg e b.jpg e 404
+// para o resto, e registra o maior número de requisições simultâneas.
+type imageServer struct {
+	mu       sync.Mutex
+	active   int
+	peak     int
+	requests int
+}
+
+func (s *imageServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	s.active++
+	if s.active > s.peak {
+		s.peak = s.active
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.active--
+		s.mu.Unlock()
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	name := filepath.Base(r.URL.Path)
+	if name != "a.jpg" && name != "b.jpg" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte("imagem " + filepath.Base(filepath.Dir(r.URL.Path))))
+}
+
+func TestMirrorRefs(t *testing.T) {
+	server := &imageServer{}
+	srv := httptest.NewServer(server)
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := database.NewMemoryStore()
+	dir := t.TempDir()
+	m := NewMirror(store, dir)
+	m.Images = models.ImageConfig{SecureBaseURL: srv.URL + "/t/p/", PosterSizes: []string{"w92", "w342", "original"}}
+	m.Sizes = map[string][]string{models.ImageKindPoster: {"w92", "w342"}}
+	m.Concurrency = 2
+
+	refs := []models.ImageRef{
+		{Kind: models.ImageKindPoster, Path: "/a.jpg"},
+		{Kind: models.ImageKindPoster, Path: "/b.jpg"},
+		{Kind: models.ImageKindPoster, Path: "/sumiu.jpg"},
+	}
+	result, err := m.MirrorRefs(ctx, refs)
+	if err != nil {
+		t.Fatalf("MirrorRefs: %v", err)
+	}
+	if result.Downloaded != 4 || result.Skipped != 0 || len(result.Errors) != 2 {
+		t.Fatalf("resultado = %+v, esperado 4 baixadas e 2 erros", result)
+	}
+	for _, err := range result.Errors {
+		if !strings.Contains(err.Error(), "/sumiu.jpg") {
+			t.Errorf("erro sem o caminho da imagem: %v", err)
+		}
+	}
+	if server.peak > m.Concurrency {
+		t.Errorf("%d downloads simultâneos, limite %d", server.peak, m.Concurrency)
+	}
+
+	// O arquivo fica em ab/cd/<sha256>.jpg, e imagens com o mesmo conteúdo
+	// compartilham o arquivo.
+	sum := sha256.Sum256([]byte("imagem w92"))
+	hash := hex.EncodeToString(sum[:])
+	want := hash[:2] + "/" + hash[2:4] + "/" + hash + ".jpg"
+	for _, path := range []string{"/a.jpg", "/b.jpg"} {
+		img, err := store.GetLocalImageContext(ctx, path, "w92")
+		if err != nil || img == nil {
+			t.Fatalf("GetLocalImageContext(%s) = %v, %v", path, img, err)
+		}
+		if img.LocalPath != want || img.SHA256 != hash || img.Bytes != int64(len("imagem w92")) {
+			t.Errorf("%s: %+v, esperado %s", path, img, want)
+		}
+		if _, err := os.Stat(m.LocalPath(img)); err != nil {
+			t.Errorf("arquivo ausente: %v", err)
+		}
+	}
+
+	// Imagens já registradas e presentes no disco não são baixadas de novo.
+	before := server.requests
+	result, err = m.MirrorRefs(ctx, refs)
+	if err != nil {
+		t.Fatalf("MirrorRefs: %v", err)
+	}
+	if result.Downloaded != 0 || result.Skipped != 4 || len(result.Errors) != 2 {
+		t.Errorf("segunda execução = %+v, esperado 4 puladas e 2 erros", result)
+	}
+	if got := server.requests - before; got != 2 {
+		t.Errorf("requisições na segunda execução = %d, esperado 2 (só as que falharam)", got)
+	}
+
+	// Um arquivo apagado do disco é baixado de novo.
+	img, _ := store.GetLocalImageContext(ctx, "/a.jpg", "w342")
+	if err := os.Remove(m.LocalPath(img)); err != nil {
+		t.Fatalf("os.Remove: %v", err)
+	}
+	if _, err := m.Download(ctx, models.ImageKindPoster, "/a.jpg", "w342"); err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if _, err := os.Stat(m.LocalPath(img)); err != nil {
+		t.Errorf("arquivo não foi baixado de novo: %v", err)
+	}
+
+	if _, err := m.Download(ctx, models.ImageKindPoster, "/a.jpg", "w999"); err == nil {
+		t.Error("Download aceitou um tamanho fora da configuração")
+	}
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ImageConfig espelha a seção "images" do endpoint /configuration do TMDB.
+type ImageConfig struct {
+	BaseURL       string   `json:"base_url"`
+	SecureBaseURL string   `json:"secure_base_url"`
+	BackdropSizes []string `json:"backdrop_sizes"`
+	LogoSizes     []string `json:"logo_sizes"`
+	PosterSizes   []string `json:"poster_sizes"`
+	ProfileSizes  []string `json:"profile_sizes"`
+	StillSizes    []string `json:"still_sizes"`
+}
+
+// DefaultImageConfig contém os valores publicados pelo TMDB e é usada até
+// que a configuração real seja carregada com
+// api.TMDBClient.LoadImageConfiguration.
+var DefaultImageConfig = ImageConfig{
+	BaseURL:       "http://image.tmdb.org/t/p/",
+	SecureBaseURL: "https://image.tmdb.org/t/p/",
+	BackdropSizes: []string{"w300", "w780", "w1280", "original"},
+	LogoSizes:     []string{"w45", "w92", "w154", "w185", "w300", "w500", "original"},
+	PosterSizes:   []string{"w92", "w154", "w185", "w342", "w500", "w780", "original"},
+	ProfileSizes:  []string{"w45", "w185", "h632", "original"},
+	StillSizes:    []string{"w92", "w185", "w300", "original"},
+}
+
+func (c ImageConfig) PosterURL(path, size string) string {
+	return c.imageURL(path, size, ImageKindPoster)
+}
+
+func (c ImageConfig) BackdropURL(path, size string) string {
+	return c.imageURL(path, size, ImageKindBackdrop)
+}
+
+func (c ImageConfig) ProfileURL(path, size string) string {
+	return c.imageURL(path, size, ImageKindProfile)
+}
+
+func (c ImageConfig) LogoURL(path, size string) string {
+	return c.imageURL(path, size, ImageKindLogo)
+}
+
+// imageURL monta a URL do caminho bruto do TMDB no tamanho pedido. Tamanhos
+// que não constam da lista do tipo kind viram "original". Caminhos que já
+// são URLs completas (linhas gravadas por versões antigas) são devolvidos
+// sem mudança.
+func (c ImageConfig) imageURL(path, size, kind string) string {
+	if path == "" {
+		return ""
+	}
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	c = c.orDefault()
+	if !containsSize(c.Sizes(kind), size) {
+		size = "original"
+	}
+	base := c.SecureBaseURL
+	if base == "" {
+		base = c.BaseURL
+	}
+	return strings.TrimSuffix(base, "/") + "/" + size + "/" + strings.TrimPrefix(path, "/")
+}
+
+// PosterSize devolve o menor tamanho de pôster com pelo menos width pixels
+// de largura, ou "original" se nenhum atender.
+func (c ImageConfig) PosterSize(width int) string {
+	return pickSize(c.orDefault().PosterSizes, width)
+}
+
+// BackdropSize devolve o menor tamanho de fundo com pelo menos width pixels
+// de largura, ou "original" se nenhum atender.
+func (c ImageConfig) BackdropSize(width int) string {
+	return pickSize(c.orDefault().BackdropSizes, width)
+}
+
+// orDefault devolve DefaultImageConfig no lugar de uma configuração sem URL
+// base, como ImageConfig{}.
+func (c ImageConfig) orDefault() ImageConfig {
+	if c.SecureBaseURL == "" && c.BaseURL == "" {
+		return DefaultImageConfig
+	}
+	return c
+}
+
+func pickSize(sizes []string, width int) string {
+	best, bestWidth := "original", 0
+	for _, s := range sizes {
+		// Apenas tamanhos "wNNN"; "hNNN" fixa a altura e "original" é o fallback.
+		var w int
+		if _, err := fmt.Sscanf(s, "w%d", &w); err != nil {
+			continue
+		}
+		if w >= width && (bestWidth == 0 || w < bestWidth) {
+			best, bestWidth = s, w
+		}
+	}
+	return best
+}
+
+func containsSize(sizes []string, size string) bool {
+	for _, s := range sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// PosterURL monta a URL do pôster com a configuração de imagens cfg,
+// normalmente a de api.TMDBClient.ImageConfig.
+func (m Movie) PosterURL(cfg ImageConfig, size string) string {
+	return cfg.PosterURL(m.PosterPath, size)
+}
+
+func (m Movie) BackdropURL(cfg ImageConfig, size string) string {
+	return cfg.BackdropURL(m.BackdropPath, size)
+}
+
+func (s TVShow) PosterURL(cfg ImageConfig, size string) string {
+	return cfg.PosterURL(s.PosterPath, size)
+}
+
+func (s TVShow) BackdropURL(cfg ImageConfig, size string) string {
+	return cfg.BackdropURL(s.BackdropPath, size)
+}
+
+const (
+	ImageKindPoster   = "poster"
+	ImageKindBackdrop = "backdrop"
+	ImageKindProfile  = "profile"
+	ImageKindLogo     = "logo"
+)
+
+// Sizes devolve a lista de tamanhos válidos para o tipo de imagem.
+func (c ImageConfig) Sizes(kind string) []string {
+	switch kind {
+	case ImageKindPoster:
+		return c.PosterSizes
+	case ImageKindBackdrop:
+		return c.BackdropSizes
+	case ImageKindProfile:
+		return c.ProfileSizes
+	case ImageKindLogo:
+		return c.LogoSizes
+	}
+	return nil
+}
+
+// URL monta a URL de qualquer tipo de imagem no tamanho pedido.
+func (c ImageConfig) URL(kind, path, size string) string {
+	return c.imageURL(path, size, kind)
+}
+
+// ImageRef identifica uma imagem do TMDB referenciada por alguma linha do
+// banco.
+type ImageRef struct {
+	Kind string
+	Path string
+}
+
+// LocalImage é uma cópia local de uma imagem do TMDB em um tamanho.
+type LocalImage struct {
+	Path         string
+	Size         string
+	Kind         string
+	LocalPath    string
+	SHA256       string
+	Bytes        int64
+	DownloadedAt time.Time
+}
@@ -0,0 +1,64 @@
+package models
+
+import "testing"
+
+func TestImageConfigURL(t *testing.T) {
+	cfg := ImageConfig{
+		SecureBaseURL: "https://img.example.com/t/p/",
+		BaseURL:       "http://img.example.com/t/p/",
+		PosterSizes:   []string{"w92", "w500", "original"},
+	}
+	tests := []struct {
+		name string
+		cfg  ImageConfig
+		path string
+		size string
+		want string
+	}{
+		{"tamanho da lista", cfg, "/abc.jpg", "w500", "https://img.example.com/t/p/w500/abc.jpg"},
+		{"original", cfg, "/abc.jpg", "original", "https://img.example.com/t/p/original/abc.jpg"},
+		{"tamanho fora da lista", cfg, "/abc.jpg", "w300", "https://img.example.com/t/p/original/abc.jpg"},
+		{"caminho sem barra", cfg, "abc.jpg", "w92", "https://img.example.com/t/p/w92/abc.jpg"},
+		{"só base_url", ImageConfig{BaseURL: "http://img.example.com/t/p", PosterSizes: []string{"w92"}},
+			"/abc.jpg", "w92", "http://img.example.com/t/p/w92/abc.jpg"},
+		{"configuração vazia", ImageConfig{}, "/abc.jpg", "w342", "https://image.tmdb.org/t/p/w342/abc.jpg"},
+		{"URL completa", cfg, "https://image.tmdb.org/t/p/w500/abc.jpg", "w92", "https://image.tmdb.org/t/p/w500/abc.jpg"},
+		{"sem caminho", cfg, "", "w500", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.PosterURL(tt.path, tt.size); got != tt.want {
+			t.Errorf("%s: PosterURL(%q, %q) = %q, esperado %q", tt.name, tt.path, tt.size, got, tt.want)
+		}
+	}
+	if got, want := DefaultImageConfig.URL(ImageKindProfile, "/p.jpg", "h632"), "https://image.tmdb.org/t/p/h632/p.jpg"; got != want {
+		t.Errorf("URL(profile) = %q, esperado %q", got, want)
+	}
+}
+
+func TestPickSize(t *testing.T) {
+	sizes := []string{"w92", "w185", "w500", "h632", "original"}
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{185, "w185"},
+		{186, "w500"},
+		{1, "w92"},
+		{0, "w92"},
+		{501, "original"},
+	}
+	for _, tt := range tests {
+		if got := pickSize(sizes, tt.width); got != tt.want {
+			t.Errorf("pickSize(%d) = %q, esperado %q", tt.width, got, tt.want)
+		}
+	}
+	if got := pickSize(nil, 100); got != "original" {
+		t.Errorf("pickSize sem tamanhos = %q, esperado original", got)
+	}
+	if got := (ImageConfig{}).PosterSize(300); got != "w342" {
+		t.Errorf("PosterSize de configuração vazia = %q, esperado w342", got)
+	}
+	if got := DefaultImageConfig.BackdropSize(800); got != "w1280" {
+		t.Errorf("BackdropSize(800) = %q, esperado w1280", got)
+	}
+}
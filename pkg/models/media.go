@@ -3,36 +3,51 @@ package models
 import "time"
 
 type Movie struct {
-	ID           int       `json:"id"`
-	Title        string    `json:"title"`
-	Overview     string    `json:"overview"`
-	ReleaseDate  string    `json:"release_date"`
-	PosterPath   string    `json:"poster_path"`
-	BackdropPath string    `json:"backdrop_path"`
-	VoteAverage  float64   `json:"vote_average"`
-	TrailerURL   string    `json:"trailer_url"`
-	Popularity   float64   `json:"popularity"`
-	CreatedAt    time.Time `json:"created_at"`
-	GenreIDs     []int     `json:"genre_ids"`
+	ID            int       `json:"id"`
+	Title         string    `json:"title"`
+	OriginalTitle string    `json:"original_title"`
+	Overview      string    `json:"overview"`
+	ReleaseDate   string    `json:"release_date"`
+	PosterPath    string    `json:"poster_path"`
+	BackdropPath  string    `json:"backdrop_path"`
+	VoteAverage   float64   `json:"vote_average"`
+	VoteCount     int       `json:"vote_count"`
+	TrailerURL    string    `json:"trailer_url"`
+	Popularity    float64   `json:"popularity"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	// Status e DeletedAt são preenchidos pelo banco; ver StatusActive.
+	Status    string     `json:"status,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	GenreIDs  []int      `json:"genre_ids"`
+	Videos    []Video    `json:"-"`
 }
 
 type TVShow struct {
-	ID           int       `json:"id"`
-	Name         string    `json:"name"`
-	Overview     string    `json:"overview"`
-	FirstAirDate string    `json:"first_air_date"`
-	PosterPath   string    `json:"poster_path"`
-	BackdropPath string    `json:"backdrop_path"`
-	VoteAverage  float64   `json:"vote_average"`
-	TrailerURL   string    `json:"trailer_url"`
-	Popularity   float64   `json:"popularity"`
-	CreatedAt    time.Time `json:"created_at"`
-	GenreIDs     []int     `json:"genre_ids"`
+	ID           int        `json:"id"`
+	Name         string     `json:"name"`
+	OriginalName string     `json:"original_name"`
+	Overview     string     `json:"overview"`
+	FirstAirDate string     `json:"first_air_date"`
+	PosterPath   string     `json:"poster_path"`
+	BackdropPath string     `json:"backdrop_path"`
+	VoteAverage  float64    `json:"vote_average"`
+	VoteCount    int        `json:"vote_count"`
+	TrailerURL   string     `json:"trailer_url"`
+	Popularity   float64    `json:"popularity"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Status       string     `json:"status,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	GenreIDs     []int      `json:"genre_ids"`
+	Videos       []Video    `json:"-"`
 }
 
 type Genre struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	MediaType string `json:"media_type,omitempty"`
+	Language  string `json:"language,omitempty"`
 }
 
 type MovieGenre struct {
@@ -44,3 +59,109 @@ type TVShowGenre struct {
 	TVShowID int
 	GenreID  int
 }
+
+const (
+	MediaTypeMovie = "movie"
+	MediaTypeTV    = "tv"
+)
+
+// Situação de um filme ou série no catálogo local. Itens que deixam de
+// existir no TMDB não são apagados de imediato: recebem DeletedAt e um dos
+// status abaixo, e voltam a StatusActive se reaparecerem.
+const (
+	StatusActive = "active"
+	// StatusNotFound marca itens cujo ID devolveu 404 (removidos ou
+	// mesclados).
+	StatusNotFound = "not_found"
+	// StatusMissing marca itens ausentes de uma coleta completa, como os
+	// ocultados por filtros ou por conteúdo adulto.
+	StatusMissing = "missing"
+)
+
+type Video struct {
+	ID          string `json:"id"`
+	MediaType   string `json:"media_type"`
+	MediaID     int    `json:"media_id"`
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Site        string `json:"site"`
+	Type        string `json:"type"`
+	Size        int    `json:"size"`
+	Official    bool   `json:"official"`
+	PublishedAt string `json:"published_at"`
+	Language    string `json:"iso_639_1"`
+	Country     string `json:"iso_3166_1"`
+}
+
+// URL devolve o endereço público do vídeo no site de origem.
+func (v Video) URL() string {
+	switch v.Site {
+	case "YouTube":
+		return "https://www.youtube.com/watch?v=" + v.Key
+	case "Vimeo":
+		return "https://vimeo.com/" + v.Key
+	}
+	return ""
+}
+
+type Country struct {
+	ISO3166_1   string `json:"iso_3166_1"`
+	EnglishName string `json:"english_name"`
+	NativeName  string `json:"native_name"`
+}
+
+type Language struct {
+	ISO639_1    string `json:"iso_639_1"`
+	EnglishName string `json:"english_name"`
+	Name        string `json:"name"`
+}
+
+type Timezone struct {
+	ISO3166_1 string   `json:"iso_3166_1"`
+	Zones     []string `json:"zones"`
+}
+
+type Department struct {
+	Department string   `json:"department"`
+	Jobs       []string `json:"jobs"`
+}
+
+// Translation é o título e a sinopse de um filme ou série em um idioma,
+// como devolvido por /movie/{id}/translations e /tv/{id}/translations.
+type Translation struct {
+	Language string `json:"iso_639_1"`
+	Country  string `json:"iso_3166_1"`
+	Title    string `json:"title"`
+	Overview string `json:"overview"`
+}
+
+// Tag devolve o idioma no formato "pt-BR".
+func (t Translation) Tag() string {
+	if t.Country == "" {
+		return t.Language
+	}
+	return t.Language + "-" + t.Country
+}
+
+// MediaChange é a mudança de um campo de filme ou série registrada pelo
+// modo de auditoria do banco.
+type MediaChange struct {
+	ID        int64     `json:"id"`
+	MediaType string    `json:"media_type"`
+	MediaID   int       `json:"media_id"`
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	SyncJob   string    `json:"sync_job,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// MediaMetric é o retrato diário da popularidade e das notas de um filme ou
+// série. Date está no formato AAAA-MM-DD.
+type MediaMetric struct {
+	ID          int     `json:"id"`
+	Date        string  `json:"date"`
+	Popularity  float64 `json:"popularity"`
+	VoteAverage float64 `json:"vote_average"`
+	VoteCount   int     `json:"vote_count"`
+}